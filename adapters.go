@@ -0,0 +1,126 @@
+package max
+
+import "github.com/256dpi/max-go/internal/block"
+
+// Float32ProcessingInstance is an object that processes audio as float32
+// samples. It is used instead of ProcessingInstance if implemented.
+type Float32ProcessingInstance interface {
+	ProcessFloat32(input, output [][]float32)
+}
+
+// InterleavedProcessingInstance is an object that processes audio as
+// interleaved float32 frames. The buffers contain the samples of all channels
+// for the first frame, followed by the samples for the second frame and so on.
+// It is used instead of ProcessingInstance if implemented.
+type InterleavedProcessingInstance interface {
+	ProcessInterleaved(input []float32, inChannels int, output []float32, outChannels int)
+}
+
+// processBuffers holds the conversion buffers of an instance. They are
+// allocated when the object is created and only reallocated on the audio thread
+// if the vector size changes afterwards.
+type processBuffers struct {
+	in32  [][]float32
+	out32 [][]float32
+	inIL  []float32
+	outIL []float32
+	block *block.Buffer
+}
+
+func newProcessBuffers(instance Instance, inputs, outputs, samples int) *processBuffers {
+	// allocate buffers of the used adapter
+	bufs := &processBuffers{}
+	switch instance.(type) {
+	case EventProcessingInstance, BlockProcessingInstance:
+	case Float32ProcessingInstance:
+		bufs.in32 = ensureFloat32(nil, inputs, samples)
+		bufs.out32 = ensureFloat32(nil, outputs, samples)
+	case InterleavedProcessingInstance:
+		bufs.inIL = ensureInterleaved(nil, inputs*samples)
+		bufs.outIL = ensureInterleaved(nil, outputs*samples)
+	}
+
+	return bufs
+}
+
+func (b *processBuffers) processFloat32(pro Float32ProcessingInstance, input, output [][]float64) {
+	// prepare buffers
+	b.in32 = ensureFloat32(b.in32, len(input), vectorSize(input, output))
+	b.out32 = ensureFloat32(b.out32, len(output), vectorSize(input, output))
+
+	// convert input
+	for i, ch := range input {
+		for j, s := range ch {
+			b.in32[i][j] = float32(s)
+		}
+	}
+
+	// process audio
+	pro.ProcessFloat32(b.in32, b.out32)
+
+	// convert output
+	for i, ch := range output {
+		for j := range ch {
+			ch[j] = float64(b.out32[i][j])
+		}
+	}
+}
+
+func (b *processBuffers) processInterleaved(pro InterleavedProcessingInstance, input, output [][]float64) {
+	// prepare buffers
+	samples := vectorSize(input, output)
+	b.inIL = ensureInterleaved(b.inIL, len(input)*samples)
+	b.outIL = ensureInterleaved(b.outIL, len(output)*samples)
+
+	// interleave input
+	for i, ch := range input {
+		for j, s := range ch {
+			b.inIL[j*len(input)+i] = float32(s)
+		}
+	}
+
+	// process audio
+	pro.ProcessInterleaved(b.inIL, len(input), b.outIL, len(output))
+
+	// deinterleave output
+	for i, ch := range output {
+		for j := range ch {
+			ch[j] = float64(b.outIL[j*len(output)+i])
+		}
+	}
+}
+
+func vectorSize(input, output [][]float64) int {
+	// get size from first channel
+	if len(input) > 0 {
+		return len(input[0])
+	} else if len(output) > 0 {
+		return len(output[0])
+	}
+
+	return 0
+}
+
+func ensureFloat32(bufs [][]float32, channels, samples int) [][]float32 {
+	// check buffers
+	if len(bufs) == channels && (channels == 0 || len(bufs[0]) == samples) {
+		return bufs
+	}
+
+	// allocate buffers
+	bufs = make([][]float32, channels)
+	for i := range bufs {
+		bufs[i] = make([]float32, samples)
+	}
+
+	return bufs
+}
+
+func ensureInterleaved(buf []float32, size int) []float32 {
+	// check buffer
+	if len(buf) == size {
+		return buf
+	}
+
+	return make([]float32, size)
+}
@@ -0,0 +1,112 @@
+package max
+
+// #include "max.h"
+import "C"
+
+import (
+	"errors"
+
+	"github.com/256dpi/max-go/audiofile"
+)
+
+// SampleRate will return the current sample rate of the audio system.
+func SampleRate() float64 {
+	return float64(C.sys_getsr())
+}
+
+// BlockSize will return the current signal vector size of the audio system.
+func BlockSize() int {
+	return int(C.sys_getblksize())
+}
+
+// AudioFileInfo describes an audio file as reported by Max.
+type AudioFileInfo struct {
+	SampleRate int
+	Channels   int
+	Frames     int
+	SampleSize int
+	Type       string
+}
+
+// GetAudioFileInfo will locate the named audio file using the Max search path
+// and return its properties as determined by Max. This supports all formats
+// known to Max.
+func GetAudioFileInfo(name string) (AudioFileInfo, error) {
+	// get info
+	var info C.t_sndfileinfo
+	ok := C.maxgo_sndfile_info(C.CString(name), &info) // string freed by receiver
+	if !ok {
+		return AudioFileInfo{}, ErrFileNotFound
+	}
+
+	// get type
+	typ := uint32(info.filetype)
+	typeName := string([]byte{byte(typ >> 24), byte(typ >> 16), byte(typ >> 8), byte(typ)})
+
+	return AudioFileInfo{
+		SampleRate: int(info.sr),
+		Channels:   int(info.nchans),
+		Frames:     int(info.frames),
+		SampleSize: int(info.sampsize),
+		Type:       typeName,
+	}, nil
+}
+
+// ReadAudioFile will locate the named WAV or AIFF file using the Max search
+// path and read it.
+func ReadAudioFile(name string) (*audiofile.File, error) {
+	// locate file
+	path, err := LocateFile(name, "WAVE", "AIFF")
+	if err != nil {
+		return nil, err
+	}
+
+	return audiofile.ReadFile(path)
+}
+
+// WriteAudioFile will write the file to the specified system path. The format
+// (WAV or AIFF) is determined from the paths extension.
+func WriteAudioFile(path string, file *audiofile.File) error {
+	return audiofile.WriteFile(path, file)
+}
+
+// Buffer will return a reference to the named buffer~ object. The object keeps
+// a buffer reference until it is freed, the returned reference therefore always
+// resolves the current buffer~ object with that name and becomes invalid if it
+// is freed. It must be called on the Max main thread.
+func (o *Object) Buffer(name string) *Ref {
+	// get or create buffer reference
+	o.mutex.Lock()
+	buf := o.buffers[name]
+	if buf == nil && !o.released {
+		buf = &bufferRef{ptr: C.buffer_ref_new((*C.t_object)(o.ptr), gensym(name))}
+		if o.buffers == nil {
+			o.buffers = map[string]*bufferRef{}
+		}
+		o.buffers[name] = buf
+	}
+	o.mutex.Unlock()
+
+	// check buffer
+	if buf == nil || buf.object() == nil {
+		return nil
+	}
+
+	return &Ref{buf: buf}
+}
+
+// LoadBuffer will load the named audio file into the named buffer~ object and
+// resize the buffer to match the file. The file is resolved using the Max
+// search path. It must be called on the Max main thread.
+func (o *Object) LoadBuffer(buffer, file string) error {
+	// get buffer
+	ref := o.Buffer(buffer)
+	if ref == nil {
+		return errors.New("buffer not found")
+	}
+
+	// replace contents
+	_, err := ref.Call("replace", file)
+
+	return err
+}
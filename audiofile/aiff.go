@@ -0,0 +1,174 @@
+package audiofile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+func decodeAIFF(data []byte) (*File, error) {
+	// prepare state
+	var file File
+	var channels int
+	var found bool
+	aifc := bytes.Equal(data[8:12], []byte("AIFC"))
+	enc := encoding{bigEndian: true}
+
+	// read chunks
+	for pos := 12; pos+8 <= len(data); {
+		// get chunk
+		id := data[pos : pos+4]
+		size := int(binary.BigEndian.Uint32(data[pos+4:]))
+		body := data[pos+8:]
+		if size > len(body) {
+			size = len(body)
+		}
+		body = body[:size]
+
+		// handle chunk
+		switch {
+		case bytes.Equal(id, []byte("COMM")):
+			if len(body) < 18 || aifc && len(body) < 22 {
+				return nil, errors.New("invalid COMM chunk")
+			}
+			channels = int(binary.BigEndian.Uint16(body[0:]))
+			file.BitDepth = int(binary.BigEndian.Uint16(body[6:]))
+			file.SampleRate = int(math.Round(decodeExtended(body[8:18])))
+			if aifc {
+				switch string(body[18:22]) {
+				case "NONE", "twos":
+				case "sowt":
+					enc.bigEndian = false
+				case "fl32", "FL32":
+					file.Float, file.BitDepth = true, 32
+				case "fl64", "FL64":
+					file.Float, file.BitDepth = true, 64
+				default:
+					return nil, ErrUnsupported
+				}
+			}
+			found = true
+		case bytes.Equal(id, []byte("SSND")):
+			if !found {
+				return nil, errors.New("missing COMM chunk")
+			}
+			if len(body) < 8 || channels == 0 {
+				return nil, errors.New("invalid SSND chunk")
+			}
+			offset := int(binary.BigEndian.Uint32(body[0:]))
+			if 8+offset > len(body) {
+				return nil, errors.New("invalid SSND chunk")
+			}
+			err := file.checkEncoding()
+			if err != nil {
+				return nil, err
+			}
+			enc.bits = file.BitDepth
+			enc.float = file.Float
+			file.Channels = decodeSamples(body[8+offset:], channels, enc)
+			return &file, nil
+		}
+
+		// advance with padding
+		pos += 8 + size + size%2
+	}
+
+	return nil, errors.New("missing SSND chunk")
+}
+
+func encodeAIFF(file *File) []byte {
+	// encode samples
+	samples := encodeSamples(file.Channels, encoding{
+		bits:      file.BitDepth,
+		float:     file.Float,
+		bigEndian: true,
+	})
+
+	// floating point samples require AIFC
+	form := "AIFF"
+	commSize := 18
+	if file.Float {
+		form = "AIFC"
+		commSize = 24
+	}
+
+	// write header
+	var buf bytes.Buffer
+	formSize := 4 + 8 + commSize + 8 + 8 + len(samples) + len(samples)%2
+	if file.Float {
+		formSize += 12
+	}
+	buf.WriteString("FORM")
+	_ = binary.Write(&buf, binary.BigEndian, uint32(formSize))
+	buf.WriteString(form)
+
+	// write version chunk
+	if file.Float {
+		buf.WriteString("FVER")
+		_ = binary.Write(&buf, binary.BigEndian, uint32(4))
+		_ = binary.Write(&buf, binary.BigEndian, uint32(0xA2805140))
+	}
+
+	// write common chunk
+	buf.WriteString("COMM")
+	_ = binary.Write(&buf, binary.BigEndian, uint32(commSize))
+	_ = binary.Write(&buf, binary.BigEndian, uint16(len(file.Channels)))
+	_ = binary.Write(&buf, binary.BigEndian, uint32(file.Frames()))
+	_ = binary.Write(&buf, binary.BigEndian, uint16(file.BitDepth))
+	buf.Write(encodeExtended(float64(file.SampleRate)))
+	if file.Float {
+		if file.BitDepth == 64 {
+			buf.WriteString("fl64")
+		} else {
+			buf.WriteString("fl32")
+		}
+		buf.Write([]byte{0, 0}) // empty pascal string with padding
+	}
+
+	// write sound data chunk
+	buf.WriteString("SSND")
+	_ = binary.Write(&buf, binary.BigEndian, uint32(8+len(samples)))
+	_ = binary.Write(&buf, binary.BigEndian, uint32(0))
+	_ = binary.Write(&buf, binary.BigEndian, uint32(0))
+	buf.Write(samples)
+	if len(samples)%2 == 1 {
+		buf.WriteByte(0)
+	}
+
+	return buf.Bytes()
+}
+
+// decodeExtended decodes an 80-bit IEEE 754 extended precision number.
+func decodeExtended(b []byte) float64 {
+	exp := int(binary.BigEndian.Uint16(b[0:]))
+	mant := binary.BigEndian.Uint64(b[2:])
+	sign := 1.0
+	if exp&0x8000 != 0 {
+		sign = -1
+		exp &= 0x7FFF
+	}
+	if exp == 0 && mant == 0 {
+		return 0
+	}
+
+	return sign * float64(mant) * math.Pow(2, float64(exp-16383-63))
+}
+
+// encodeExtended encodes an 80-bit IEEE 754 extended precision number.
+func encodeExtended(v float64) []byte {
+	b := make([]byte, 10)
+	if v == 0 {
+		return b
+	}
+	var sign uint16
+	if v < 0 {
+		sign = 0x8000
+		v = -v
+	}
+	frac, exp := math.Frexp(v) // v = frac * 2^exp, 0.5 <= frac < 1
+	binary.BigEndian.PutUint16(b[0:], sign|uint16(exp-1+16383))
+	binary.BigEndian.PutUint64(b[2:], uint64(frac*(1<<64)))
+
+	return b
+}
@@ -0,0 +1,163 @@
+// Package audiofile implements reading and writing of WAV and AIFF files in
+// pure Go.
+package audiofile
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// Format describes an audio file format.
+type Format int
+
+// The available formats.
+const (
+	WAV Format = iota
+	AIFF
+)
+
+// ErrUnsupported is returned for unsupported formats and encodings.
+var ErrUnsupported = errors.New("unsupported audio file")
+
+// File is a decoded audio file.
+type File struct {
+	// The sample rate in Hz.
+	SampleRate int
+
+	// The bit depth of the samples (8, 16, 24, 32 or 64).
+	BitDepth int
+
+	// Whether the samples are stored as floating point numbers. Only bit
+	// depths of 32 and 64 are supported for floating point samples.
+	Float bool
+
+	// The deinterleaved samples per channel in the range -1 to 1.
+	Channels [][]float64
+}
+
+// Frames will return the number of frames.
+func (f *File) Frames() int {
+	if len(f.Channels) == 0 {
+		return 0
+	}
+
+	return len(f.Channels[0])
+}
+
+// FormatFromPath will return the format based on the files extension.
+func FormatFromPath(path string) (Format, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".wav", ".wave":
+		return WAV, true
+	case ".aif", ".aiff", ".aifc":
+		return AIFF, true
+	default:
+		return 0, false
+	}
+}
+
+// Decode will decode the provided WAV or AIFF file data.
+func Decode(data []byte) (*File, error) {
+	// check header
+	if len(data) < 12 {
+		return nil, ErrUnsupported
+	}
+
+	// decode format
+	switch {
+	case bytes.Equal(data[0:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WAVE")):
+		return decodeWAV(data)
+	case bytes.Equal(data[0:4], []byte("FORM")) && (bytes.Equal(data[8:12], []byte("AIFF")) || bytes.Equal(data[8:12], []byte("AIFC"))):
+		return decodeAIFF(data)
+	default:
+		return nil, ErrUnsupported
+	}
+}
+
+// Encode will encode the provided file in the specified format.
+func Encode(file *File, format Format) ([]byte, error) {
+	// check file
+	err := file.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	// encode format
+	switch format {
+	case WAV:
+		return encodeWAV(file), nil
+	case AIFF:
+		return encodeAIFF(file), nil
+	default:
+		return nil, ErrUnsupported
+	}
+}
+
+// ReadFile will read and decode the specified file.
+func ReadFile(path string) (*File, error) {
+	// read file
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Decode(data)
+}
+
+// WriteFile will encode and write the file to the specified path. The format
+// is determined from the paths extension.
+func WriteFile(path string, file *File) error {
+	// get format
+	format, ok := FormatFromPath(path)
+	if !ok {
+		return ErrUnsupported
+	}
+
+	// encode file
+	data, err := Encode(file, format)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+func (f *File) validate() error {
+	// check sample rate
+	if f.SampleRate <= 0 {
+		return errors.New("invalid sample rate")
+	}
+
+	// check bit depth
+	err := f.checkEncoding()
+	if err != nil {
+		return err
+	}
+
+	// check channels
+	if len(f.Channels) == 0 {
+		return errors.New("missing channels")
+	}
+	for _, ch := range f.Channels {
+		if len(ch) != len(f.Channels[0]) {
+			return errors.New("channel length mismatch")
+		}
+	}
+
+	return nil
+}
+
+func (f *File) checkEncoding() error {
+	// check bit depth
+	switch {
+	case f.Float && (f.BitDepth == 32 || f.BitDepth == 64):
+	case !f.Float && (f.BitDepth == 8 || f.BitDepth == 16 || f.BitDepth == 24 || f.BitDepth == 32):
+	default:
+		return ErrUnsupported
+	}
+
+	return nil
+}
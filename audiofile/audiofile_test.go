@@ -0,0 +1,207 @@
+package audiofile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+)
+
+var testSamples = [][]float64{
+	{0, 0.5, -0.5, 1, -1, 0.25, -0.001, 0.333},
+	{-1, 1, 0.1, -0.1, 0, 0.75, 0.999, -0.999},
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []Format{WAV, AIFF} {
+		for _, enc := range []struct {
+			bits  int
+			float bool
+		}{
+			{8, false},
+			{16, false},
+			{24, false},
+			{32, false},
+			{32, true},
+			{64, true},
+		} {
+			for _, channels := range [][][]float64{testSamples, testSamples[:1], {testSamples[0][:3]}} {
+				name := fmt.Sprintf("%d/%d/%t/%dx%d", format, enc.bits, enc.float, len(channels), len(channels[0]))
+
+				// encode file
+				data, err := Encode(&File{
+					SampleRate: 44100,
+					BitDepth:   enc.bits,
+					Float:      enc.float,
+					Channels:   channels,
+				}, format)
+				if err != nil {
+					t.Fatalf("%s: %s", name, err)
+				}
+
+				// decode file
+				file, err := Decode(data)
+				if err != nil {
+					t.Fatalf("%s: %s", name, err)
+				}
+
+				// check header
+				if file.SampleRate != 44100 || file.BitDepth != enc.bits || file.Float != enc.float {
+					t.Errorf("%s: unexpected header %d %d %t", name, file.SampleRate, file.BitDepth, file.Float)
+				}
+				if len(file.Channels) != len(channels) || file.Frames() != len(channels[0]) {
+					t.Fatalf("%s: unexpected size %dx%d", name, len(file.Channels), file.Frames())
+				}
+
+				// check samples
+				for i, ch := range channels {
+					for j, exp := range ch {
+						checkSample(t, name, exp, file.Channels[i][j], enc.bits, enc.float)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSampleRates(t *testing.T) {
+	for _, format := range []Format{WAV, AIFF} {
+		for _, rate := range []int{8000, 22050, 44100, 48000, 96000, 192000} {
+			// encode file
+			data, err := Encode(&File{
+				SampleRate: rate,
+				BitDepth:   16,
+				Channels:   testSamples,
+			}, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// decode file
+			file, err := Decode(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if file.SampleRate != rate {
+				t.Errorf("%d: expected %d, got %d", format, rate, file.SampleRate)
+			}
+		}
+	}
+}
+
+func TestDecodeAIFC(t *testing.T) {
+	for _, item := range []struct {
+		compression string
+		bits        int
+		float       bool
+		order       binary.ByteOrder
+	}{
+		{"NONE", 16, false, binary.BigEndian},
+		{"twos", 24, false, binary.BigEndian},
+		{"sowt", 16, false, binary.LittleEndian},
+		{"sowt", 24, false, binary.LittleEndian},
+		{"sowt", 32, false, binary.LittleEndian},
+		{"fl32", 32, true, binary.BigEndian},
+		{"FL64", 64, true, binary.BigEndian},
+	} {
+		name := fmt.Sprintf("%s/%d", item.compression, item.bits)
+
+		// prepare samples
+		samples := encodeSamples(testSamples, encoding{
+			bits:      item.bits,
+			float:     item.float,
+			bigEndian: item.order == binary.BigEndian,
+		})
+
+		// decode file
+		file, err := Decode(makeAIFC(item.compression, item.bits, len(testSamples), samples))
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+
+		// check header
+		if file.SampleRate != 48000 || file.BitDepth != item.bits || file.Float != item.float {
+			t.Errorf("%s: unexpected header %d %d %t", name, file.SampleRate, file.BitDepth, file.Float)
+		}
+		if len(file.Channels) != len(testSamples) || file.Frames() != len(testSamples[0]) {
+			t.Fatalf("%s: unexpected size %dx%d", name, len(file.Channels), file.Frames())
+		}
+
+		// check samples
+		for i, ch := range testSamples {
+			for j, exp := range ch {
+				checkSample(t, name, exp, file.Channels[i][j], item.bits, item.float)
+			}
+		}
+	}
+
+	// check unsupported compression
+	_, err := Decode(makeAIFC("ulaw", 16, 1, make([]byte, 4)))
+	if err != ErrUnsupported {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	for _, data := range [][]byte{
+		nil,
+		[]byte("RIFF"),
+		[]byte("RIFF\x00\x00\x00\x00WAVE"),
+		[]byte("FORM\x00\x00\x00\x00AIFF"),
+		[]byte("OggS\x00\x00\x00\x00\x00\x00\x00\x00"),
+	} {
+		_, err := Decode(data)
+		if err == nil {
+			t.Errorf("%q: expected error", data)
+		}
+	}
+}
+
+func checkSample(t *testing.T, name string, exp, act float64, bits int, float bool) {
+	t.Helper()
+
+	// get expected value and tolerance
+	var tolerance float64
+	switch {
+	case float && bits == 32:
+		exp = float64(float32(exp))
+	case !float:
+		tolerance = 1.5 / (math.Pow(2, float64(bits-1)) - 1)
+	}
+
+	// check value
+	if math.Abs(exp-act) > tolerance {
+		t.Errorf("%s: expected %f, got %f", name, exp, act)
+	}
+}
+
+func makeAIFC(compression string, bits, channels int, samples []byte) []byte {
+	// write common chunk
+	var comm bytes.Buffer
+	_ = binary.Write(&comm, binary.BigEndian, uint16(channels))
+	_ = binary.Write(&comm, binary.BigEndian, uint32(len(samples)/channels/(bits/8)))
+	_ = binary.Write(&comm, binary.BigEndian, uint16(bits))
+	comm.Write(encodeExtended(48000))
+	comm.WriteString(compression)
+	comm.Write([]byte{0, 0})
+
+	// write file with an unknown chunk before the sound data
+	var buf bytes.Buffer
+	buf.WriteString("FORM")
+	_ = binary.Write(&buf, binary.BigEndian, uint32(4+8+comm.Len()+8+3+1+8+8+len(samples)))
+	buf.WriteString("AIFC")
+	buf.WriteString("COMM")
+	_ = binary.Write(&buf, binary.BigEndian, uint32(comm.Len()))
+	buf.Write(comm.Bytes())
+	buf.WriteString("NAME")
+	_ = binary.Write(&buf, binary.BigEndian, uint32(3))
+	buf.Write([]byte{'f', 'o', 'o', 0})
+	buf.WriteString("SSND")
+	_ = binary.Write(&buf, binary.BigEndian, uint32(8+len(samples)))
+	_ = binary.Write(&buf, binary.BigEndian, uint32(0))
+	_ = binary.Write(&buf, binary.BigEndian, uint32(0))
+	buf.Write(samples)
+
+	return buf.Bytes()
+}
@@ -0,0 +1,117 @@
+package audiofile
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+type encoding struct {
+	bits      int
+	float     bool
+	bigEndian bool
+	unsigned  bool
+}
+
+func (e encoding) order() binary.ByteOrder {
+	if e.bigEndian {
+		return binary.BigEndian
+	}
+
+	return binary.LittleEndian
+}
+
+func decodeSamples(data []byte, channels int, enc encoding) [][]float64 {
+	// get sizes
+	size := enc.bits / 8
+	frames := len(data) / (size * channels)
+
+	// allocate channels
+	list := make([][]float64, channels)
+	for i := range list {
+		list[i] = make([]float64, frames)
+	}
+
+	// decode samples
+	order := enc.order()
+	scale := math.Pow(2, float64(enc.bits-1))
+	for i := 0; i < frames; i++ {
+		for j := 0; j < channels; j++ {
+			buf := data[(i*channels+j)*size:]
+			var v float64
+			switch {
+			case enc.float && enc.bits == 32:
+				v = float64(math.Float32frombits(order.Uint32(buf)))
+			case enc.float && enc.bits == 64:
+				v = math.Float64frombits(order.Uint64(buf))
+			case enc.bits == 8 && enc.unsigned:
+				v = float64(int(buf[0])-128) / scale
+			case enc.bits == 8:
+				v = float64(int8(buf[0])) / scale
+			case enc.bits == 16:
+				v = float64(int16(order.Uint16(buf))) / scale
+			case enc.bits == 24:
+				var n int32
+				if enc.bigEndian {
+					n = int32(buf[0])<<16 | int32(buf[1])<<8 | int32(buf[2])
+				} else {
+					n = int32(buf[2])<<16 | int32(buf[1])<<8 | int32(buf[0])
+				}
+				n = n << 8 >> 8 // sign extend
+				v = float64(n) / scale
+			case enc.bits == 32:
+				v = float64(int32(order.Uint32(buf))) / scale
+			}
+			list[j][i] = v
+		}
+	}
+
+	return list
+}
+
+func encodeSamples(channels [][]float64, enc encoding) []byte {
+	// get sizes
+	size := enc.bits / 8
+	frames := len(channels[0])
+
+	// allocate buffer
+	data := make([]byte, frames*len(channels)*size)
+
+	// encode samples
+	order := enc.order()
+	scale := math.Pow(2, float64(enc.bits-1)) - 1
+	for i := 0; i < frames; i++ {
+		for j, ch := range channels {
+			buf := data[(i*len(channels)+j)*size:]
+			v := ch[i]
+			switch {
+			case enc.float && enc.bits == 32:
+				order.PutUint32(buf, math.Float32bits(float32(v)))
+				continue
+			case enc.float && enc.bits == 64:
+				order.PutUint64(buf, math.Float64bits(v))
+				continue
+			}
+			n := int32(math.Round(math.Max(-1, math.Min(1, v)) * scale))
+			switch enc.bits {
+			case 8:
+				if enc.unsigned {
+					buf[0] = byte(n + 128)
+				} else {
+					buf[0] = byte(int8(n))
+				}
+			case 16:
+				order.PutUint16(buf, uint16(int16(n)))
+			case 24:
+				if enc.bigEndian {
+					buf[0], buf[1], buf[2] = byte(n>>16), byte(n>>8), byte(n)
+				} else {
+					buf[0], buf[1], buf[2] = byte(n), byte(n>>8), byte(n>>16)
+				}
+			case 32:
+				order.PutUint32(buf, uint32(n))
+			}
+		}
+	}
+
+	return data
+}
@@ -0,0 +1,114 @@
+package audiofile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	wavPCM        = 1
+	wavFloat      = 3
+	wavExtensible = 0xFFFE
+)
+
+func decodeWAV(data []byte) (*File, error) {
+	// prepare state
+	var file File
+	var channels int
+	var format uint16
+	var found bool
+
+	// read chunks
+	for pos := 12; pos+8 <= len(data); {
+		// get chunk
+		id := data[pos : pos+4]
+		size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		body := data[pos+8:]
+		if size > len(body) {
+			size = len(body)
+		}
+		body = body[:size]
+
+		// handle chunk
+		switch {
+		case bytes.Equal(id, []byte("fmt ")):
+			if len(body) < 16 {
+				return nil, errors.New("invalid fmt chunk")
+			}
+			format = binary.LittleEndian.Uint16(body[0:])
+			channels = int(binary.LittleEndian.Uint16(body[2:]))
+			file.SampleRate = int(binary.LittleEndian.Uint32(body[4:]))
+			file.BitDepth = int(binary.LittleEndian.Uint16(body[14:]))
+			if format == wavExtensible && len(body) >= 26 {
+				format = binary.LittleEndian.Uint16(body[24:])
+			}
+			found = true
+		case bytes.Equal(id, []byte("data")):
+			if !found {
+				return nil, errors.New("missing fmt chunk")
+			}
+			if format != wavPCM && format != wavFloat || channels == 0 {
+				return nil, ErrUnsupported
+			}
+			file.Float = format == wavFloat
+			err := file.checkEncoding()
+			if err != nil {
+				return nil, err
+			}
+			file.Channels = decodeSamples(body, channels, encoding{
+				bits:     file.BitDepth,
+				float:    file.Float,
+				unsigned: file.BitDepth == 8,
+			})
+			return &file, nil
+		}
+
+		// advance with padding
+		pos += 8 + size + size%2
+	}
+
+	return nil, errors.New("missing data chunk")
+}
+
+func encodeWAV(file *File) []byte {
+	// encode samples
+	samples := encodeSamples(file.Channels, encoding{
+		bits:     file.BitDepth,
+		float:    file.Float,
+		unsigned: file.BitDepth == 8,
+	})
+
+	// get format
+	format := uint16(wavPCM)
+	if file.Float {
+		format = wavFloat
+	}
+
+	// write header
+	var buf bytes.Buffer
+	blockAlign := len(file.Channels) * file.BitDepth / 8
+	buf.WriteString("RIFF")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(36+len(samples)+len(samples)%2))
+	buf.WriteString("WAVE")
+
+	// write format chunk
+	buf.WriteString("fmt ")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(16))
+	_ = binary.Write(&buf, binary.LittleEndian, format)
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(len(file.Channels)))
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(file.SampleRate))
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(file.SampleRate*blockAlign))
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(blockAlign))
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(file.BitDepth))
+
+	// write data chunk
+	buf.WriteString("data")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(samples)))
+	buf.Write(samples)
+	if len(samples)%2 == 1 {
+		buf.WriteByte(0)
+	}
+
+	return buf.Bytes()
+}
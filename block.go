@@ -0,0 +1,52 @@
+package max
+
+// #include "max.h"
+import "C"
+
+// BlockProcessingInstance is an object that processes audio in blocks of a
+// fixed size independent of the vector size used by Max. Every hop samples the
+// instance receives the last size input samples and returns size output
+// samples, which are overlap-added. A hop smaller than the size results in
+// overlapping blocks, in which case the instance must apply a window that sums
+// to unity gain. The rebuffering introduces a latency of size samples, which is
+// reported using the "latency" attribute. The object is not created if the size
+// or hop is invalid. It is used instead of ProcessingInstance if implemented.
+type BlockProcessingInstance interface {
+	BlockSize() (size, hop int)
+	ProcessBlock(input, output [][]float64)
+}
+
+// SetLatency will set the latency of the object in samples as reported by the
+// read-only "latency" attribute. It must be called on the Max main thread.
+func (o *Object) SetLatency(samples int) {
+	C.maxgo_set_latency(o.ptr, C.long(samples))
+}
+
+// Latency will return the latency of the object in samples.
+func (o *Object) Latency() int {
+	return int(C.maxgo_get_latency(o.ptr))
+}
+
+func (o *Object) signalInlets() int {
+	// count signal inlets
+	var n int
+	for _, in := range o.in {
+		if in.typ == Signal {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (o *Object) signalOutlets() int {
+	// count signal outlets
+	var n int
+	for _, out := range o.out {
+		if out.typ == Signal {
+			n++
+		}
+	}
+
+	return n
+}
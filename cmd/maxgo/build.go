@@ -0,0 +1,330 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/otiai10/copy"
+)
+
+func build(outDir string, targets []target) error {
+	// clear directory (see top notes)
+	err := os.RemoveAll(outDir)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(outDir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	// build targets in parallel
+	var wg sync.WaitGroup
+	errs := make([]error, len(targets))
+	for i, t := range targets {
+		wg.Add(1)
+		go func(i int, t target) {
+			defer wg.Done()
+			errs[i] = buildTarget(outDir, t)
+		}(i, t)
+	}
+	wg.Wait()
+
+	// collect errors
+	var messages []string
+	for i, err := range errs {
+		if err != nil {
+			messages = append(messages, fmt.Sprintf("%s: %s", targets[i].name, err))
+		}
+	}
+	if len(messages) == 1 && len(targets) == 1 {
+		return errs[0]
+	} else if len(messages) > 0 {
+		return fmt.Errorf("%d of %d externals failed:\n%s", len(messages), len(targets), strings.Join(messages, "\n"))
+	}
+
+	return nil
+}
+
+func buildTarget(outDir string, t target) error {
+	// build
+	switch runtime.GOOS {
+	case "darwin":
+		err := track("build darwin "+t.name, func() error {
+			return buildDarwin(outDir, t)
+		})
+		if err != nil {
+			return err
+		}
+		if sign != "" {
+			err = track("sign darwin "+t.name, func() error {
+				return signBundle(filepath.Join(outDir, t.name+".mxo"))
+			})
+			if err != nil {
+				return err
+			}
+		}
+		artifact(filepath.Join(outDir, t.name+".mxo"))
+		if cross {
+			err = track("build windows "+t.name, func() error {
+				return buildWindows(outDir, t)
+			})
+			if err != nil {
+				return err
+			}
+			for _, file := range windowsExternals(t) {
+				artifact(filepath.Join(outDir, file))
+			}
+		}
+	case "windows":
+		err := track("build windows "+t.name, func() error {
+			return buildWindows(outDir, t)
+		})
+		if err != nil {
+			return err
+		}
+		for _, file := range windowsExternals(t) {
+			artifact(filepath.Join(outDir, file))
+		}
+	default:
+		return fmt.Errorf("unsupported platform %s", runtime.GOOS)
+	}
+
+	return nil
+}
+
+func hostExternals(t target) []string {
+	// get files
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{t.name + ".mxo"}
+	case "windows":
+		return windowsExternals(t)
+	default:
+		return nil
+	}
+}
+
+func windowsExternals(t target) []string {
+	// get files
+	var list []string
+	for _, arch := range t.manifest.Archs("windows") {
+		list = append(list, t.name+windowsExtension(arch))
+	}
+
+	return list
+}
+
+// Max on Windows loads x64 externals from ".mxe64" files and native ARM64
+// externals from ".mxearm64" files. Both may live side by side in a package.
+
+func windowsExtension(arch string) string {
+	if arch == "arm64" {
+		return ".mxearm64"
+	}
+
+	return ".mxe64"
+}
+
+func installExternals(outDir string, targets []target) error {
+	// log
+	logf("installing externals...")
+
+	// get home dir
+	user, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+
+	// prepare path
+	dir, err := filepath.Abs(filepath.Join(user, "Documents", "Max 8", "Packages", install, "externals"))
+	if err != nil {
+		return err
+	}
+
+	// log
+	infof("target: %s", dir)
+
+	// create path
+	err = os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	// install externals
+	for _, t := range targets {
+		for _, external := range hostExternals(t) {
+			// copy external (see top notes)
+			err = os.RemoveAll(filepath.Join(dir, external))
+			if err != nil {
+				return err
+			}
+			err = copy.Copy(filepath.Join(outDir, external), filepath.Join(dir, external))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func buildDarwin(outDir string, t target) error {
+	// log
+	logf("building %s...", t.name)
+
+	// prepare bin file
+	bin := filepath.Join(outDir, t.name)
+
+	// build architectures
+	var bins []string
+	for _, arch := range t.manifest.Archs("darwin") {
+		err := execute("go",
+			goBuild(t, "-o", bin+"-"+arch),
+			[]string{"CGO_ENABLED=1", "GOARCH=" + arch, "CGO_LDFLAGS=-Wl,-no_fixup_chains"},
+		)
+		if err != nil {
+			return err
+		}
+		bins = append(bins, bin+"-"+arch)
+	}
+
+	// assemble universal binary
+	err := execute("lipo",
+		append([]string{"-create", "-output", bin}, bins...),
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	// remove intermediate binaries and headers
+	for _, file := range bins {
+		_ = os.Remove(file)
+		_ = os.Remove(file + ".h")
+	}
+
+	return bundleDarwin(outDir, t.name, bin)
+}
+
+// bundleDarwin will assemble the external bundle from the provided universal
+// binary, which is moved into the bundle. It does not depend on the macOS
+// toolchain and may be used with binaries built elsewhere.
+func bundleDarwin(outDir, name, bin string) error {
+	// ensure directory
+	err := os.MkdirAll(filepath.Join(outDir, name+".mxo", "Contents", "MacOS"), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	// move binary
+	err = os.Rename(bin, filepath.Join(outDir, name+".mxo", "Contents", "MacOS", name))
+	if err != nil {
+		return err
+	}
+
+	// write info plist
+	err = ioutil.WriteFile(filepath.Join(outDir, name+".mxo", "Contents", "Info.plist"), []byte(infoPlist(name)), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	// write package info
+	err = ioutil.WriteFile(filepath.Join(outDir, name+".mxo", "Contents", "PkgInfo"), []byte(pkgInfo), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func buildWindows(outDir string, t target) error {
+	// build architectures
+	for _, arch := range t.manifest.Archs("windows") {
+		// prepare env
+		env := []string{"GOOS=windows", "GOARCH=" + arch, "CGO_ENABLED=1"}
+
+		// use zig when cross compiling
+		if runtime.GOOS != "windows" || runtime.GOARCH != arch {
+			// log
+			logf("cross building %s (%s)...", t.name, arch)
+
+			// check zig
+			_, err := exec.LookPath("zig")
+			if err != nil {
+				return errors.New("missing zig command (you may need to install zig)")
+			}
+
+			// add compiler
+			switch arch {
+			case "amd64":
+				env = append(env, `CC=zig cc -target x86_64-windows-gnu`)
+			case "arm64":
+				env = append(env, `CC=zig cc -target aarch64-windows-gnu`)
+			}
+		} else {
+			// log
+			logf("building %s (%s)...", t.name, arch)
+		}
+
+		// build
+		err := execute("go",
+			goBuild(t, "-o", filepath.Join(outDir, t.name+windowsExtension(arch))),
+			env,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func goBuild(t target, args ...string) []string {
+	// prepare args
+	list := []string{"build", "-buildmode=c-shared"}
+	if verbose {
+		list = append(list, "-v")
+	}
+
+	// add args and package
+	list = append(list, args...)
+	list = append(list, t.pkg)
+
+	return list
+}
+
+func execute(bin string, args []string, env []string) error {
+	// construct
+	cmd := exec.Command(bin, args...)
+	cmd.Env = append(env, os.Environ()...)
+
+	// capture output if not verbose
+	var output bytes.Buffer
+	if verbose {
+		cmd.Stdout = logWriter()
+		cmd.Stderr = logWriter()
+	} else {
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+	}
+
+	// run
+	err := cmd.Run()
+	if err != nil {
+		if output.Len() > 0 {
+			return fmt.Errorf("%s failed: %w\n%s", bin, err, strings.TrimSpace(output.String()))
+		}
+		return fmt.Errorf("%s failed: %w", bin, err)
+	}
+
+	return nil
+}
@@ -1,15 +1,15 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
-
-	"github.com/otiai10/copy"
+	"strings"
 )
 
 // On macOS, we need to make sure that we always write external binaries to a
@@ -17,180 +17,336 @@ import (
 // crashes with a SIGKILL when loading the modified external.
 // https://developer.apple.com/documentation/security/updating_mac_software
 
-var name = flag.String("name", "", "the name of the external")
-var out = flag.String("out", "out", "the output directory")
-var cross = flag.Bool("cross", false, "cross compile for Windows on macOS")
-var install = flag.String("install", "", "install into specified package")
+var name string
+var out string
+var cross bool
+var install string
+var sign string
+var entitlements string
+var verbose bool
+var quiet bool
+var report bool
+var prebuilt bool
+var patterns []string
+
+type command struct {
+	name  string
+	usage string
+	run   func(outDir string, targets []target) error
+}
+
+var commands = []command{
+	{name: "build", usage: "build the externals", run: func(outDir string, targets []target) error {
+		err := build(outDir, targets)
+		if err != nil || install == "" {
+			return err
+		}
+		return track("install", func() error {
+			return installExternals(outDir, targets)
+		})
+	}},
+	{name: "install", usage: "build and install the externals into a package", run: func(outDir string, targets []target) error {
+		err := build(outDir, targets)
+		if err != nil {
+			return err
+		}
+		return track("install", func() error {
+			return installExternals(outDir, targets)
+		})
+	}},
+	{name: "package", usage: "build the externals and create a notarization-ready zip archive", run: func(outDir string, targets []target) error {
+		if prebuilt {
+			return packagePrebuilt(outDir, targets)
+		}
+		err := build(outDir, targets)
+		if err != nil {
+			return err
+		}
+		var externals []string
+		for _, t := range targets {
+			externals = append(externals, hostExternals(t)...)
+		}
+		return track("package", func() error {
+			return archiveExternals(outDir, externals)
+		})
+	}},
+	{name: "clean", usage: "remove the output directory", run: func(outDir string, _ []target) error {
+		return clean(outDir)
+	}},
+	{name: "watch", usage: "rebuild and reinstall the externals on source changes", run: func(outDir string, targets []target) error {
+		watch(outDir, targets)
+		return nil
+	}},
+}
+
+// usageError is returned for invalid invocations.
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
 
 func main() {
-	// parse flags
-	flag.Parse()
+	// run command
+	err := runCommand(os.Args[1:])
+	if err == nil {
+		return
+	}
 
-	// log
-	fmt.Println("==> checking system...")
+	// print error
+	fmt.Fprintf(os.Stderr, "maxgo: %s\n", err)
 
-	// check go
-	_, err := exec.LookPath("go")
-	if err != nil {
-		panic("missing go command (you may need to install Go)")
+	// exit with code
+	var usageErr *usageError
+	if errors.As(err, &usageErr) {
+		os.Exit(2)
 	}
+	os.Exit(1)
+}
 
-	// check cross compile
-	if *cross {
-		// check OS
-		if runtime.GOOS != "darwin" {
-			panic("cannot cross compile for macOS from Windows")
-		}
+func runCommand(args []string) error {
+	// get command, default to build for flag-only invocations
+	cmdName := "build"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmdName = args[0]
+		args = args[1:]
+	}
 
-		// check zig
-		_, err := exec.LookPath("zig")
-		if err != nil {
-			panic("missing zig command (you may need to install zig)")
+	// find command
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == cmdName {
+			cmd = &commands[i]
 		}
 	}
+	if cmd == nil {
+		printUsage(os.Stderr)
+		return &usageError{msg: fmt.Sprintf("unknown command %q", cmdName)}
+	}
 
-	// log
-	fmt.Println("==> preparing build...")
+	// parse flags
+	set := flags(cmd.name)
+	err := set.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if err != nil {
+		return &usageError{msg: err.Error()}
+	}
+
+	// get patterns
+	patterns = set.Args()
+
+	// check arguments
+	if cmd.name == "clean" && set.NArg() > 0 {
+		return &usageError{msg: fmt.Sprintf("unexpected arguments: %s", strings.Join(set.Args(), " "))}
+	}
 
-	// check name
-	if *name == "" {
-		panic("missing external name")
+	// check verbosity
+	if verbose && quiet {
+		return &usageError{msg: "-v and -q are mutually exclusive"}
 	}
 
-	// get out dir
-	var outDir = filepath.Join(".", "out")
-	if *out != "" {
-		outDir, err = filepath.Abs(*out)
-		check(err)
+	// check report
+	if report && cmd.name == "watch" {
+		return &usageError{msg: "-json is not supported by watch"}
 	}
 
-	// print
-	fmt.Printf("name: %s\n", *name)
-	fmt.Printf("out: %s\n", outDir)
+	// prepare report
+	rep := newReport(cmd.name)
 
-	// clear directory (see top notes)
-	check(os.RemoveAll(outDir))
-	check(os.MkdirAll(outDir, os.ModePerm))
+	// run command
+	err = func() error {
+		// check system
+		err := checkSystem(cmd.name)
+		if err != nil {
+			return err
+		}
 
-	// build
-	switch runtime.GOOS {
-	case "darwin":
-		buildDarwin(outDir)
-		if *cross {
-			crossBuildWindows(outDir)
+		// get out dir
+		outDir, err := filepath.Abs(out)
+		if err != nil {
+			return err
 		}
-	case "windows":
-		buildWindows(outDir)
-	}
 
-	// install
-	if *install != "" {
 		// log
-		fmt.Println("==> installing external...")
+		logf("preparing %s...", cmd.name)
+
+		// resolve targets
+		var targets []target
+		if cmd.name != "clean" {
+			targets, err = loadTargets(patterns)
+			if err != nil {
+				return err
+			}
+		}
 
-		// get home dir
-		user, err := os.UserHomeDir()
-		check(err)
+		// log
+		for _, t := range targets {
+			infof("external: %s (%s)", t.name, t.pkg)
+			rep.Externals = append(rep.Externals, t.name)
+		}
+		infof("out: %s", outDir)
 
-		// prepare path
-		dir, err := filepath.Abs(filepath.Join(user, "Documents", "Max 8", "Packages", *install, "externals"))
-		check(err)
+		return cmd.run(outDir, targets)
+	}()
 
-		// log
-		fmt.Printf("target: %s\n", dir)
-
-		// create path
-		check(os.MkdirAll(dir, os.ModePerm))
-
-		// copy external (see top notes)
-		switch runtime.GOOS {
-		case "darwin":
-			check(os.RemoveAll(filepath.Join(dir, *name+".mxo")))
-			check(copy.Copy(filepath.Join(outDir, *name+".mxo"), filepath.Join(dir, *name+".mxo")))
-		case "windows":
-			check(os.RemoveAll(filepath.Join(dir, *name+".mxe64")))
-			check(copy.Copy(filepath.Join(outDir, *name+".mxe64"), filepath.Join(dir, *name+".mxe64")))
+	// write report
+	if report {
+		rep.finish(err)
+		werr := rep.write(os.Stdout)
+		if werr != nil && err == nil {
+			err = werr
 		}
 	}
+	if err != nil {
+		return err
+	}
 
 	// log
-	fmt.Println("==> done!")
+	logf("done!")
+
+	return nil
 }
 
-func buildDarwin(outDir string) {
-	// log
-	fmt.Println("==> building...")
-
-	// prepare bin file
-	bin := filepath.Join("out", *name)
-
-	// build arm64 and amd64
-	run("go",
-		[]string{"build", "-v", "-buildmode=c-shared", "-o", bin + "-arm64"},
-		[]string{"CGO_ENABLED=1", "GOARCH=arm64", "CGO_LDFLAGS=-Wl,-no_fixup_chains"},
-	)
-	run("go",
-		[]string{"build", "-v", "-buildmode=c-shared", "-o", bin + "-amd64"},
-		[]string{"CGO_ENABLED=1", "GOARCH=amd64", "CGO_LDFLAGS=-Wl,-no_fixup_chains"},
-	)
-
-	// assemble universal binary
-	run("lipo",
-		[]string{"-create", "-output", bin, bin + "-amd64", bin + "-arm64"},
-		nil,
-	)
-
-	// ensure directory
-	check(os.MkdirAll(filepath.Join(outDir, *name+".mxo", "Contents", "MacOS"), os.ModePerm))
-
-	// copy binary
-	check(os.Rename(bin, filepath.Join(outDir, *name+".mxo", "Contents", "MacOS", *name)))
-
-	// write info plist
-	check(ioutil.WriteFile(filepath.Join(outDir, *name+".mxo", "Contents", "Info.plist"), []byte(infoPlist(*name)), os.ModePerm))
-
-	// write package info
-	check(ioutil.WriteFile(filepath.Join(outDir, *name+".mxo", "Contents", "PkgInfo"), []byte(pkgInfo), os.ModePerm))
+func loadTargets(patterns []string) ([]target, error) {
+	// resolve targets
+	targets, err := resolveTargets(patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	// override name
+	if name != "" && len(targets) == 1 {
+		targets[0].name = name
+	} else if name != "" && len(targets) > 1 {
+		return nil, &usageError{msg: "-name cannot be used with multiple externals"}
+	}
+
+	return targets, nil
+}
+
+func flags(cmdName string) *flag.FlagSet {
+	// create set
+	set := flag.NewFlagSet("maxgo "+cmdName, flag.ContinueOnError)
+	set.SetOutput(os.Stderr)
+
+	// add common flags
+	set.StringVar(&name, "name", "", "the name of the external (defaults to the registered class name)")
+	set.StringVar(&out, "out", "out", "the output directory")
+	set.BoolVar(&verbose, "v", false, "print verbose build output")
+	set.BoolVar(&quiet, "q", false, "only print errors")
+	set.BoolVar(&report, "json", false, "print a JSON build report to stdout")
+
+	// add build flags
+	if cmdName != "clean" {
+		set.BoolVar(&cross, "cross", false, "cross compile for Windows on macOS")
+		set.StringVar(&sign, "sign", "", "sign the external with the specified identity (\"-\" for ad-hoc)")
+		set.StringVar(&entitlements, "entitlements", "", "the entitlements file used when signing")
+	}
+
+	// add command flags
+	switch cmdName {
+	case "install", "watch":
+		set.StringVar(&install, "package", "", "the package to install into")
+	case "build":
+		set.StringVar(&install, "install", "", "install into specified package (deprecated, use install)")
+	case "package":
+		set.BoolVar(&prebuilt, "prebuilt", false, "sign and archive the externals in the output directory without building")
+	}
+
+	// set usage
+	set.Usage = func() {
+		if cmdName == "clean" {
+			fmt.Fprintf(set.Output(), "usage: maxgo %s [flags]\n\n", cmdName)
+		} else {
+			fmt.Fprintf(set.Output(), "usage: maxgo %s [flags] [packages]\n\n", cmdName)
+		}
+		set.PrintDefaults()
+		fmt.Fprintln(set.Output())
+		printUsage(set.Output())
+	}
+
+	return set
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.usage)
+	}
 }
 
-func buildWindows(outDir string) {
+func checkSystem(cmdName string) error {
+	// skip for clean
+	if cmdName == "clean" {
+		return nil
+	}
+
 	// log
-	fmt.Println("==> building...")
+	logf("checking system...")
+
+	// check package
+	if cmdName == "install" && install == "" {
+		return &usageError{msg: "missing package name (use -package)"}
+	}
 
-	// build
-	run("go",
-		[]string{"build", "-v", "-buildmode=c-shared", "-o", filepath.Join(outDir, *name+".mxe64")},
-		[]string{"CGO_ENABLED=1"},
-	)
+	// check go
+	_, err := exec.LookPath("go")
+	if err != nil {
+		return errors.New("missing go command (you may need to install Go)")
+	}
+
+	// check cross compile
+	if cross {
+		// check OS
+		if runtime.GOOS != "darwin" {
+			return errors.New("cross compilation is only supported on macOS")
+		}
+
+		// check zig
+		_, err := exec.LookPath("zig")
+		if err != nil {
+			return errors.New("missing zig command (you may need to install zig)")
+		}
+	}
+
+	// check signing
+	if sign != "" && (runtime.GOOS == "darwin" || prebuilt) {
+		_, err := signer()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-func crossBuildWindows(outDir string) {
+func clean(outDir string) error {
 	// log
-	fmt.Println("==> cross building...")
+	logf("cleaning...")
 
-	// build
-	run("go",
-		[]string{"build", "-v", "-buildmode=c-shared", "-o", filepath.Join(outDir, *name+".mxe64")},
-		[]string{`CC=zig cc -target x86_64-windows-gnu`, "GOOS=windows", "GOARCH=amd64", "CGO_ENABLED=1"},
-	)
+	return os.RemoveAll(outDir)
 }
 
-func run(bin string, args []string, env []string) {
-	// construct
-	cmd := exec.Command(bin, args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stdout
-	cmd.Env = append(env, os.Environ()...)
+func logWriter() io.Writer {
+	// keep stdout free for the report
+	if report {
+		return os.Stderr
+	}
 
-	// run
-	err := cmd.Run()
-	if err != nil {
-		panic("command failed")
+	return os.Stdout
+}
+
+func logf(format string, args ...interface{}) {
+	if !quiet {
+		fmt.Fprintf(logWriter(), "==> "+format+"\n", args...)
 	}
 }
 
-func check(err error) {
-	if err != nil {
-		panic(err)
+func infof(format string, args ...interface{}) {
+	if !quiet {
+		fmt.Fprintf(logWriter(), format+"\n", args...)
 	}
 }
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Report describes the result of a command.
+type Report struct {
+	Command   string     `json:"command"`
+	Externals []string   `json:"externals"`
+	OS        string     `json:"os"`
+	GoVersion string     `json:"go_version"`
+	Success   bool       `json:"success"`
+	Error     string     `json:"error,omitempty"`
+	Duration  float64    `json:"duration"`
+	Steps     []Step     `json:"steps"`
+	Artifacts []Artifact `json:"artifacts"`
+
+	start time.Time
+	mutex sync.Mutex
+}
+
+// Step describes a single timed step of a command.
+type Step struct {
+	Name     string  `json:"name"`
+	Duration float64 `json:"duration"`
+}
+
+// Artifact describes a produced file or bundle.
+type Artifact struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+var current *Report
+
+func newReport(cmdName string) *Report {
+	// create report
+	current = &Report{
+		Command:   cmdName,
+		Externals: []string{},
+		OS:        runtime.GOOS,
+		Steps:     []Step{},
+		Artifacts: []Artifact{},
+		start:     time.Now(),
+	}
+
+	return current
+}
+
+func (r *Report) finish(err error) {
+	// set result
+	r.Success = err == nil
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	// set duration
+	r.Duration = time.Since(r.start).Seconds()
+
+	// get go version
+	ver, verErr := exec.Command("go", "env", "GOVERSION").Output()
+	if verErr == nil {
+		r.GoVersion = strings.TrimSpace(string(ver))
+	}
+}
+
+func (r *Report) write(w io.Writer) error {
+	// encode report
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(r)
+}
+
+func track(step string, fn func() error) error {
+	// get time
+	start := time.Now()
+
+	// run step
+	err := fn()
+
+	// record step
+	if current != nil {
+		current.mutex.Lock()
+		current.Steps = append(current.Steps, Step{
+			Name:     step,
+			Duration: time.Since(start).Seconds(),
+		})
+		current.mutex.Unlock()
+	}
+
+	return err
+}
+
+func artifact(path string) {
+	// check report
+	if current == nil {
+		return
+	}
+
+	// get total size
+	var size int64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	// record artifact
+	current.mutex.Lock()
+	current.Artifacts = append(current.Artifacts, Artifact{
+		Path: path,
+		Size: size,
+	})
+	current.mutex.Unlock()
+}
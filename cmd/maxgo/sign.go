@@ -0,0 +1,217 @@
+package main
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// On Apple Silicon, externals must carry at least an ad-hoc signature to be
+// loaded by Max. Bundles are signed with Apple's codesign tool if available and
+// otherwise with rcodesign, which also allows signing and inspecting bundles on
+// other platforms. Notarization requires the hardened runtime, which is enabled
+// when signing with an identity, and a zip archive of the bundle that can be
+// submitted using "xcrun notarytool submit".
+// Signing and archiving only operate on assembled bundles and can therefore
+// also be run on prebuilt bundles, e.g. on Linux using rcodesign.
+
+func signer() (string, error) {
+	// prefer codesign on macOS
+	if runtime.GOOS == "darwin" {
+		if _, err := exec.LookPath("codesign"); err == nil {
+			return "codesign", nil
+		}
+	}
+
+	// otherwise use rcodesign for ad-hoc signatures
+	if _, err := exec.LookPath("rcodesign"); err == nil {
+		if sign != "-" {
+			return "", errors.New("rcodesign only supports ad-hoc signing (use -sign -)")
+		}
+		return "rcodesign", nil
+	}
+
+	return "", errors.New("missing codesign or rcodesign command (you may need to install Xcode)")
+}
+
+func signBundle(bundle string) error {
+	// log
+	logf("signing %s...", filepath.Base(bundle))
+
+	// get signer
+	bin, err := signer()
+	if err != nil {
+		return err
+	}
+
+	// prepare args
+	var args []string
+	switch bin {
+	case "codesign":
+		args = []string{"--force", "--sign", sign}
+		if sign != "-" {
+			args = append(args, "--options", "runtime", "--timestamp")
+		}
+		if entitlements != "" {
+			args = append(args, "--entitlements", entitlements)
+		}
+	case "rcodesign":
+		args = []string{"sign"}
+		if entitlements != "" {
+			args = append(args, "--entitlements-xml-file", entitlements)
+		}
+	}
+
+	// sign bundle
+	err = execute(bin, append(args, bundle), nil)
+	if err != nil {
+		return err
+	}
+
+	// verify signature
+	switch bin {
+	case "codesign":
+		return execute(bin, []string{"--verify", "--strict", bundle}, nil)
+	case "rcodesign":
+		return execute(bin, []string{"verify", bundle}, nil)
+	}
+
+	return nil
+}
+
+func prebuiltExternals(outDir string, t target) []string {
+	// collect existing externals
+	var list []string
+	for _, external := range append([]string{t.name + ".mxo"}, windowsExternals(t)...) {
+		_, err := os.Stat(filepath.Join(outDir, external))
+		if err == nil {
+			list = append(list, external)
+		}
+	}
+
+	return list
+}
+
+func packagePrebuilt(outDir string, targets []target) error {
+	// collect externals
+	var externals []string
+	for _, t := range targets {
+		list := prebuiltExternals(outDir, t)
+		if len(list) == 0 {
+			return fmt.Errorf("missing prebuilt external %s in %s", t.name, outDir)
+		}
+		externals = append(externals, list...)
+	}
+
+	// sign bundles
+	if sign != "" {
+		for _, external := range externals {
+			if filepath.Ext(external) != ".mxo" {
+				continue
+			}
+			err := track("sign darwin "+strings.TrimSuffix(external, ".mxo"), func() error {
+				return signBundle(filepath.Join(outDir, external))
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return track("package", func() error {
+		return archiveExternals(outDir, externals)
+	})
+}
+
+func archiveExternals(outDir string, externals []string) error {
+	// log
+	logf("archiving...")
+
+	// check externals
+	if len(externals) == 0 {
+		return nil
+	}
+
+	// determine archive
+	archive := "externals.zip"
+	if len(externals) == 1 {
+		archive = externals[0] + ".zip"
+	}
+
+	// create file
+	file, err := os.Create(filepath.Join(outDir, archive))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// create writer
+	writer := zip.NewWriter(file)
+
+	// add files while keeping the bundles as the top level directories
+	for _, external := range externals {
+		err = filepath.Walk(filepath.Join(outDir, external), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			// get relative path
+			rel, err := filepath.Rel(outDir, path)
+			if err != nil {
+				return err
+			}
+
+			// prepare header
+			header, err := zip.FileInfoHeader(info)
+			if err != nil {
+				return err
+			}
+			header.Name = filepath.ToSlash(rel)
+
+			// add directory
+			if info.IsDir() {
+				header.Name += "/"
+				_, err = writer.CreateHeader(header)
+				return err
+			}
+
+			// add file
+			header.Method = zip.Deflate
+			w, err := writer.CreateHeader(header)
+			if err != nil {
+				return err
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(w, f)
+
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// finish archive
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+
+	// log
+	infof("archive: %s", file.Name())
+
+	// record artifact
+	artifact(file.Name())
+
+	return nil
+}
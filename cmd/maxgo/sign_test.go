@@ -0,0 +1,244 @@
+package main
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func init() {
+	quiet = true
+}
+
+func TestArchiveExternals(t *testing.T) {
+	// prepare bundles
+	outDir := t.TempDir()
+	for _, name := range []string{"foo", "bar"} {
+		bin := filepath.Join(outDir, name)
+		err := ioutil.WriteFile(bin, []byte(name), os.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = bundleDarwin(outDir, name, bin)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, item := range []struct {
+		externals []string
+		archive   string
+		files     []string
+	}{
+		{
+			externals: []string{"foo.mxo"},
+			archive:   "foo.mxo.zip",
+			files: []string{
+				"foo.mxo/",
+				"foo.mxo/Contents/",
+				"foo.mxo/Contents/Info.plist",
+				"foo.mxo/Contents/MacOS/",
+				"foo.mxo/Contents/MacOS/foo",
+				"foo.mxo/Contents/PkgInfo",
+			},
+		},
+		{
+			externals: []string{"foo.mxo", "bar.mxo"},
+			archive:   "externals.zip",
+			files: []string{
+				"bar.mxo/",
+				"bar.mxo/Contents/",
+				"bar.mxo/Contents/Info.plist",
+				"bar.mxo/Contents/MacOS/",
+				"bar.mxo/Contents/MacOS/bar",
+				"bar.mxo/Contents/PkgInfo",
+				"foo.mxo/",
+				"foo.mxo/Contents/",
+				"foo.mxo/Contents/Info.plist",
+				"foo.mxo/Contents/MacOS/",
+				"foo.mxo/Contents/MacOS/foo",
+				"foo.mxo/Contents/PkgInfo",
+			},
+		},
+	} {
+		// create archive
+		err := archiveExternals(outDir, item.externals)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// read archive
+		reader, err := zip.OpenReader(filepath.Join(outDir, item.archive))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// check files
+		var files []string
+		for _, file := range reader.File {
+			files = append(files, file.Name)
+			if file.Name == "foo.mxo/Contents/MacOS/foo" && file.Method != zip.Deflate {
+				t.Errorf("%s: expected deflate, got %d", file.Name, file.Method)
+			}
+		}
+		_ = reader.Close()
+		sort.Strings(files)
+		if !reflect.DeepEqual(files, item.files) {
+			t.Errorf("%s: unexpected files %v", item.archive, files)
+		}
+	}
+}
+
+func TestPrebuiltExternals(t *testing.T) {
+	// prepare bundle
+	outDir := t.TempDir()
+	bin := filepath.Join(outDir, "foo")
+	err := ioutil.WriteFile(bin, []byte("foo"), os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = bundleDarwin(outDir, "foo", bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// check externals
+	list := prebuiltExternals(outDir, target{name: "foo"})
+	if !reflect.DeepEqual(list, []string{"foo.mxo"}) {
+		t.Errorf("unexpected externals %v", list)
+	}
+	list = prebuiltExternals(outDir, target{name: "bar"})
+	if len(list) != 0 {
+		t.Errorf("unexpected externals %v", list)
+	}
+}
+
+func TestSignBundle(t *testing.T) {
+	// stub signers are shell scripts
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+
+	// install stub signers that record their arguments
+	bin := t.TempDir()
+	log := filepath.Join(bin, "log")
+	for _, name := range []string{"codesign", "rcodesign"} {
+		script := "#!/bin/sh\necho " + name + " \"$@\" >> " + log + "\n"
+		err := ioutil.WriteFile(filepath.Join(bin, name), []byte(script), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", bin)
+
+	// restore flags
+	defer func(s, e string) {
+		sign, entitlements = s, e
+	}(sign, entitlements)
+
+	for _, item := range []struct {
+		sign         string
+		entitlements string
+		codesign     []string
+		rcodesign    []string
+	}{
+		{
+			sign: "-",
+			codesign: []string{
+				"codesign --force --sign - B",
+				"codesign --verify --strict B",
+			},
+			rcodesign: []string{
+				"rcodesign sign B",
+				"rcodesign verify B",
+			},
+		},
+		{
+			sign:         "-",
+			entitlements: "E",
+			codesign: []string{
+				"codesign --force --sign - --entitlements E B",
+				"codesign --verify --strict B",
+			},
+			rcodesign: []string{
+				"rcodesign sign --entitlements-xml-file E B",
+				"rcodesign verify B",
+			},
+		},
+		{
+			sign:         "Developer ID Application: Test",
+			entitlements: "E",
+			codesign: []string{
+				"codesign --force --sign Developer ID Application: Test --options runtime --timestamp --entitlements E B",
+				"codesign --verify --strict B",
+			},
+		},
+	} {
+		// set flags
+		sign, entitlements = item.sign, item.entitlements
+
+		// get expected calls
+		expected := item.rcodesign
+		if runtime.GOOS == "darwin" {
+			expected = item.codesign
+		}
+
+		// sign bundle
+		_ = os.Remove(log)
+		err := signBundle("B")
+		if expected == nil {
+			if err == nil {
+				t.Errorf("%s: expected error", item.sign)
+			}
+			continue
+		} else if err != nil {
+			t.Fatalf("%s: %s", item.sign, err)
+		}
+
+		// check calls
+		data, err := ioutil.ReadFile(log)
+		if err != nil {
+			t.Fatal(err)
+		}
+		calls := strings.Split(strings.TrimSpace(string(data)), "\n")
+		if !reflect.DeepEqual(calls, expected) {
+			t.Errorf("%s: unexpected calls %q", item.sign, calls)
+		}
+	}
+}
+
+func TestSignBundleVerifyFailure(t *testing.T) {
+	// stub signers are shell scripts
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+
+	// install stub signers that fail to verify
+	bin := t.TempDir()
+	for _, name := range []string{"codesign", "rcodesign"} {
+		script := "#!/bin/sh\ncase \"$1\" in verify|--verify) echo invalid signature >&2; exit 1;; esac\n"
+		err := ioutil.WriteFile(filepath.Join(bin, name), []byte(script), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", bin)
+
+	// restore flags
+	defer func(s string) {
+		sign = s
+	}(sign)
+	sign = "-"
+
+	// sign bundle
+	err := signBundle("B")
+	if err == nil || !strings.Contains(err.Error(), "invalid signature") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const modulePath = "github.com/256dpi/max-go"
+
+const manifestFile = "maxgo.json"
+
+// Manifest is an optional "maxgo.json" file in an external's package directory.
+type Manifest struct {
+	// The name of the external.
+	Name string `json:"name"`
+
+	// The architectures to build per operating system, e.g.
+	// {"darwin": ["arm64"], "windows": ["amd64", "arm64"]}.
+	Architectures map[string][]string `json:"architectures"`
+}
+
+var defaultArchitectures = map[string][]string{
+	"darwin":  {"arm64", "amd64"},
+	"windows": {"amd64"},
+}
+
+// Archs returns the architectures to build for the specified operating system.
+func (m Manifest) Archs(goos string) []string {
+	if archs := m.Architectures[goos]; len(archs) > 0 {
+		return archs
+	}
+
+	return defaultArchitectures[goos]
+}
+
+func (m Manifest) validate() error {
+	// check architectures
+	for goos, archs := range m.Architectures {
+		if goos != "darwin" && goos != "windows" {
+			return fmt.Errorf("unsupported operating system %q", goos)
+		}
+		for _, arch := range archs {
+			if arch != "amd64" && arch != "arm64" {
+				return fmt.Errorf("unsupported architecture %q for %s", arch, goos)
+			}
+		}
+	}
+
+	return nil
+}
+
+type target struct {
+	name     string
+	pkg      string
+	dir      string
+	manifest Manifest
+}
+
+func readManifest(dir string) (Manifest, error) {
+	// read file
+	data, err := ioutil.ReadFile(filepath.Join(dir, manifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return Manifest{}, nil
+	} else if err != nil {
+		return Manifest{}, err
+	}
+
+	// decode manifest
+	var manifest Manifest
+	err = json.Unmarshal(data, &manifest)
+	if err == nil {
+		err = manifest.validate()
+	}
+	if err != nil {
+		return Manifest{}, fmt.Errorf("invalid %s: %w", filepath.Join(dir, manifestFile), err)
+	}
+
+	return manifest, nil
+}
+
+func resolveTargets(patterns []string) ([]target, error) {
+	// default to current directory
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+
+	// collect targets
+	var list []target
+	seen := map[string]bool{}
+	for _, pattern := range patterns {
+		// list packages
+		cmd := exec.Command("go", "list", "-f", "{{.Name}}\t{{.ImportPath}}\t{{.Dir}}\t{{join .GoFiles \"\\t\"}}\t{{join .CgoFiles \"\\t\"}}", pattern)
+		output, err := cmd.Output()
+		if err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				return nil, fmt.Errorf("invalid target %q: %s", pattern, strings.TrimSpace(string(exitErr.Stderr)))
+			}
+			return nil, err
+		}
+
+		// check packages
+		wildcard := strings.Contains(pattern, "...")
+		for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+			// parse line
+			fields := strings.Split(line, "\t")
+			if len(fields) < 3 || fields[0] != "main" || seen[fields[1]] {
+				continue
+			}
+
+			// get externals name from registration
+			className, ok, err := findClass(fields[2], fields[3:])
+			if err != nil {
+				return nil, err
+			}
+
+			// skip unrelated main packages matched by wildcards
+			if !ok && wildcard {
+				continue
+			}
+
+			// read manifest
+			manifest, err := readManifest(fields[2])
+			if err != nil {
+				return nil, err
+			}
+
+			// determine name
+			name := manifest.Name
+			if name == "" {
+				name = className
+			}
+			if name == "" {
+				name = filepath.Base(fields[2])
+			}
+
+			// add target
+			seen[fields[1]] = true
+			list = append(list, target{
+				name:     name,
+				pkg:      fields[1],
+				dir:      fields[2],
+				manifest: manifest,
+			})
+		}
+	}
+
+	// check list
+	if len(list) == 0 {
+		return nil, fmt.Errorf("no externals found in %s", strings.Join(patterns, " "))
+	}
+
+	// check names
+	names := map[string]string{}
+	for _, t := range list {
+		if other, ok := names[t.name]; ok {
+			return nil, fmt.Errorf("externals %s and %s are both named %q", other, t.pkg, t.name)
+		}
+		names[t.name] = t.pkg
+	}
+
+	return list, nil
+}
+
+func findClass(dir string, files []string) (string, bool, error) {
+	// parse files
+	fset := token.NewFileSet()
+	for _, file := range files {
+		// skip empty fields
+		if file == "" {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, filepath.Join(dir, file), nil, 0)
+		if err != nil {
+			return "", false, err
+		}
+
+		// get import name
+		var pkgName string
+		for _, imp := range f.Imports {
+			path, _ := strconv.Unquote(imp.Path.Value)
+			if path != modulePath {
+				continue
+			}
+			pkgName = "max"
+			if imp.Name != nil {
+				pkgName = imp.Name.Name
+			}
+		}
+		if pkgName == "" {
+			continue
+		}
+
+		// find calls to max.Register or max.Init
+		var name string
+		var found bool
+		ast.Inspect(f, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok || found {
+				return !found
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || sel.Sel.Name != "Register" && sel.Sel.Name != "Init" {
+				return true
+			}
+			if ident, ok := sel.X.(*ast.Ident); !ok || ident.Name != pkgName {
+				return true
+			}
+
+			// get name if literal
+			found = true
+			if len(call.Args) > 0 {
+				if lit, ok := call.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+					name, _ = strconv.Unquote(lit.Value)
+				}
+			}
+
+			return false
+		})
+		if found {
+			return name, true, nil
+		}
+	}
+
+	return "", false, nil
+}
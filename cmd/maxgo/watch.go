@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// The watcher polls the module sources instead of relying on platform file
+// system notifications. This keeps the CLI free of additional dependencies and
+// works the same on macOS and Windows. Targets are resolved again when a
+// manifest changes to pick up new names and architectures.
+
+const watchInterval = 500 * time.Millisecond
+
+func watch(outDir string, targets []target) {
+	// get module root
+	root := moduleRoot()
+
+	// log
+	logf("watching sources...")
+	infof("root: %s", root)
+
+	// get current state
+	last, manifests := snapshot(root, outDir)
+
+	// run initial build
+	rebuild(outDir, targets)
+
+	for {
+		// await next check
+		time.Sleep(watchInterval)
+
+		// get state
+		state, stateManifests := snapshot(root, outDir)
+		if state == last {
+			continue
+		}
+
+		// wait until changes have settled
+		for {
+			time.Sleep(watchInterval)
+			next, nextManifests := snapshot(root, outDir)
+			if next == state {
+				break
+			}
+			state, stateManifests = next, nextManifests
+		}
+
+		// update state
+		last = state
+
+		// log
+		logf("change detected...")
+
+		// re-resolve targets if a manifest changed
+		if stateManifests != manifests {
+			list, err := loadTargets(patterns)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "maxgo: resolve failed: %s\n", err)
+				continue
+			}
+			manifests = stateManifests
+			targets = list
+			for _, t := range targets {
+				infof("external: %s (%s)", t.name, t.pkg)
+			}
+		}
+
+		// rebuild
+		rebuild(outDir, targets)
+	}
+}
+
+func rebuild(outDir string, targets []target) {
+	// get time
+	start := time.Now()
+
+	// build (see top notes)
+	err := build(outDir, targets)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "maxgo: build failed: %s\n", err)
+		return
+	}
+
+	// install
+	if install != "" {
+		err = installExternals(outDir, targets)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "maxgo: install failed: %s\n", err)
+			return
+		}
+	}
+
+	// log
+	logf("done! (%s)", time.Since(start).Round(time.Millisecond))
+}
+
+func moduleRoot() string {
+	// get module file
+	out, err := exec.Command("go", "env", "GOMOD").Output()
+	if err != nil {
+		return "."
+	}
+
+	// fall back to the working directory outside of modules
+	mod := strings.TrimSpace(string(out))
+	if mod == "" || mod == os.DevNull {
+		return "."
+	}
+
+	return filepath.Dir(mod)
+}
+
+func snapshot(root, outDir string) (string, string) {
+	// prepare builders
+	var state, manifests strings.Builder
+
+	// walk module directory
+	_ = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		// ignore errors
+		if err != nil {
+			return nil
+		}
+
+		// skip output and hidden directories
+		if info.IsDir() {
+			abs, _ := filepath.Abs(path)
+			if abs == outDir || path != root && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// check file
+		if !watchedFile(info.Name()) {
+			return nil
+		}
+
+		// add file, manifests are also tracked separately
+		line := fmt.Sprintf("%s:%d:%d\n", path, info.Size(), info.ModTime().UnixNano())
+		state.WriteString(line)
+		if info.Name() == manifestFile {
+			manifests.WriteString(line)
+		}
+
+		return nil
+	})
+
+	return state.String(), manifests.String()
+}
+
+func watchedFile(name string) bool {
+	// check name
+	switch name {
+	case "go.mod", "go.sum", manifestFile:
+		return true
+	}
+
+	// check extension
+	switch filepath.Ext(name) {
+	case ".go", ".c", ".h":
+		return true
+	}
+
+	return false
+}
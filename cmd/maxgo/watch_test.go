@@ -0,0 +1,73 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	// prepare module
+	root := t.TempDir()
+	outDir := filepath.Join(root, "out")
+	for _, file := range []string{
+		"go.mod",
+		"main.go",
+		"lib/helper.c",
+		"lib/helper.h",
+		"ext/maxgo.json",
+		"README.md",
+		"out/ext.mxo",
+		".git/HEAD",
+	} {
+		path := filepath.Join(root, file)
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(path, []byte(file), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// check files
+	state, manifests := snapshot(root, outDir)
+	for _, file := range []string{"go.mod", "main.go", "helper.c", "helper.h", "maxgo.json"} {
+		if !strings.Contains(state, file) {
+			t.Errorf("expected %s in state:\n%s", file, state)
+		}
+	}
+	for _, file := range []string{"README.md", "ext.mxo", "HEAD"} {
+		if strings.Contains(state, file) {
+			t.Errorf("unexpected %s in state:\n%s", file, state)
+		}
+	}
+	if strings.Count(manifests, "\n") != 1 || !strings.Contains(manifests, "maxgo.json") {
+		t.Errorf("unexpected manifests:\n%s", manifests)
+	}
+
+	// change source
+	future := time.Now().Add(time.Hour)
+	err := os.Chtimes(filepath.Join(root, "lib/helper.c"), future, future)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state2, manifests2 := snapshot(root, outDir)
+	if state2 == state || manifests2 != manifests {
+		t.Error("expected source change only")
+	}
+
+	// change manifest
+	err = ioutil.WriteFile(filepath.Join(root, "ext/maxgo.json"), []byte(`{"name":"foo"}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state3, manifests3 := snapshot(root, outDir)
+	if state3 == state2 || manifests3 == manifests2 {
+		t.Error("expected manifest change")
+	}
+}
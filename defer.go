@@ -0,0 +1,342 @@
+package max
+
+// #include "max.h"
+import "C"
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Call is a pending deferred or scheduled function call.
+type Call struct {
+	ref   uint64
+	fn    func()
+	owner *Object
+}
+
+// DeferMetrics describes the state of the deferred call queue.
+type DeferMetrics struct {
+	// The number of calls waiting to be run.
+	Pending int
+
+	// The number of calls that have been run.
+	Executed uint64
+
+	// The number of calls that have been cancelled.
+	Cancelled uint64
+
+	// The number of calls that have been dropped because their object has
+	// been freed.
+	Dropped uint64
+
+	// The number of batches of low priority calls that have been run.
+	Batches uint64
+}
+
+var calls = map[uint64]*Call{}
+var batch []*Call
+var spare []*Call
+var batchScheduled bool
+var metrics DeferMetrics
+var callsMutex sync.Mutex
+
+// IsMainThread will return if the Max main thead is executing.
+func IsMainThread() bool {
+	return C.systhread_ismainthread() == 1
+}
+
+// GetDeferMetrics will return the current deferred call metrics.
+func GetDeferMetrics() DeferMetrics {
+	// acquire mutex
+	callsMutex.Lock()
+	defer callsMutex.Unlock()
+
+	return metrics
+}
+
+//export maxgoYield
+func maxgoYield(ref uint64) {
+	// run batch if requested
+	if ref == 0 {
+		runBatch()
+		return
+	}
+
+	// get call
+	callsMutex.Lock()
+	call := calls[ref]
+	delete(calls, ref)
+	callsMutex.Unlock()
+
+	// run call if available
+	if call != nil {
+		call.run()
+	}
+}
+
+func runBatch() {
+	// swap batch
+	callsMutex.Lock()
+	list := batch
+	batch = spare[:0]
+	spare = nil
+	batchScheduled = false
+	metrics.Batches++
+	callsMutex.Unlock()
+
+	// run calls
+	for i, call := range list {
+		call.run()
+		list[i] = nil
+	}
+
+	// keep list for reuse
+	callsMutex.Lock()
+	if spare == nil {
+		spare = list[:0]
+	}
+	callsMutex.Unlock()
+}
+
+func (c *Call) run() {
+	// take function, calls running outside the main thread are tracked so
+	// that freeing the object can await them
+	callsMutex.Lock()
+	fn := c.fn
+	c.fn = nil
+	tracked := fn != nil && c.owner != nil && !IsMainThread()
+	if fn != nil {
+		metrics.Pending--
+		metrics.Executed++
+	}
+	if tracked {
+		c.owner.running.Add(1)
+		defer c.owner.running.Done()
+	}
+	callsMutex.Unlock()
+
+	// record activity
+	if fn != nil {
+		recordActivity()
+	}
+
+	// execute function if still pending
+	if fn != nil && c.owner != nil {
+		measure(&c.owner.stats.deferred, fn)
+	} else if fn != nil {
+		fn()
+	}
+}
+
+// Cancel will cancel the call if it is still pending. It returns whether the
+// call has been cancelled before it was run.
+func (c *Call) Cancel() bool {
+	// acquire mutex
+	callsMutex.Lock()
+	defer callsMutex.Unlock()
+
+	// check function
+	if c.fn == nil {
+		return false
+	}
+
+	// remove function
+	c.fn = nil
+	delete(calls, c.ref)
+	metrics.Pending--
+	metrics.Cancelled++
+
+	return true
+}
+
+func enqueue(owner *Object, fn func(), batched bool) (*Call, bool) {
+	// acquire mutex
+	callsMutex.Lock()
+	defer callsMutex.Unlock()
+
+	// create call
+	call := &Call{owner: owner}
+
+	// drop call if object has been freed
+	if owner != nil && owner.freed {
+		metrics.Dropped++
+		return call, false
+	}
+
+	// set function
+	call.fn = fn
+	metrics.Pending++
+
+	// add to batch if requested
+	if batched {
+		batch = append(batch, call)
+		if batchScheduled {
+			return call, false
+		}
+		batchScheduled = true
+		return call, true
+	}
+
+	// store call
+	call.ref = atomic.AddUint64(&counter, 1)
+	calls[call.ref] = call
+
+	return call, true
+}
+
+func dropCalls(owner *Object) {
+	// await running calls
+	defer owner.running.Wait()
+
+	// acquire mutex
+	callsMutex.Lock()
+	defer callsMutex.Unlock()
+
+	// mark object
+	owner.freed = true
+
+	// drop batched calls
+	for _, call := range batch {
+		if call.owner == owner && call.fn != nil {
+			call.fn = nil
+			metrics.Pending--
+			metrics.Dropped++
+		}
+	}
+
+	// drop stored calls
+	for ref, call := range calls {
+		if call.owner == owner {
+			call.fn = nil
+			delete(calls, ref)
+			metrics.Pending--
+			metrics.Dropped++
+		}
+	}
+}
+
+// Defer will run the provided function on the Max main thread. The function is
+// added to the back of the low priority queue. Functions deferred in quick
+// succession are run in a single batch.
+func Defer(fn func()) *Call {
+	return deferLow(nil, fn)
+}
+
+// DeferFront will run the provided function on the Max main thread. If called
+// from the main thread the function is run immediately, otherwise it is added
+// to the front of the queue.
+func DeferFront(fn func()) *Call {
+	return deferFront(nil, fn)
+}
+
+// Schedule will run the provided function on the Max scheduler thread after
+// the specified delay. If overdrive is disabled the scheduler runs on the main
+// thread.
+func Schedule(delay time.Duration, fn func()) *Call {
+	return schedule(nil, delay, fn)
+}
+
+// Defer will run the provided function on the Max main thread like the global
+// Defer. The call is dropped if the object is freed before it is run.
+func (o *Object) Defer(fn func()) *Call {
+	return deferLow(o, fn)
+}
+
+// DeferFront will run the provided function on the Max main thread like the
+// global DeferFront. The call is dropped if the object is freed before it is
+// run.
+func (o *Object) DeferFront(fn func()) *Call {
+	return deferFront(o, fn)
+}
+
+// Schedule will run the provided function on the Max scheduler thread like the
+// global Schedule. The call is dropped if the object is freed before it is run
+// and freeing the object waits for the call to return if it is running. The
+// function must therefore not wait for the main thread, e.g. using DeferSync.
+func (o *Object) Schedule(delay time.Duration, fn func()) *Call {
+	return schedule(o, delay, fn)
+}
+
+func deferLow(owner *Object, fn func()) *Call {
+	// enqueue function
+	call, ok := enqueue(owner, fn, true)
+	if !ok {
+		return call
+	}
+
+	// defer batch
+	C.maxgo_defer(0)
+
+	return call
+}
+
+func deferFront(owner *Object, fn func()) *Call {
+	// enqueue function
+	call, ok := enqueue(owner, fn, false)
+	if !ok {
+		return call
+	}
+
+	// run immediately if on main thread
+	if IsMainThread() {
+		maxgoYield(call.ref)
+		return call
+	}
+
+	// defer call
+	C.maxgo_defer_front(C.ulonglong(call.ref))
+
+	return call
+}
+
+func schedule(owner *Object, delay time.Duration, fn func()) *Call {
+	// enqueue function
+	call, ok := enqueue(owner, fn, false)
+	if !ok {
+		return call
+	}
+
+	// schedule call
+	C.maxgo_schedule(C.ulonglong(call.ref), C.double(float64(delay)/float64(time.Millisecond)))
+
+	return call
+}
+
+// DeferSync will run the provided function on the Max main thread and wait for
+// it to return. If called from the main thread the function is run immediately
+// to prevent a deadlock. The function must not be called from the audio thread.
+func DeferSync(fn func() error) error {
+	_, err := DeferResult(func() (struct{}, error) {
+		return struct{}{}, fn()
+	})
+	return err
+}
+
+// DeferResult will run the provided function on the Max main thread and wait
+// for its result. If called from the main thread the function is run
+// immediately to prevent a deadlock. The function must not be called from the
+// audio thread.
+func DeferResult[T any](fn func() (T, error)) (T, error) {
+	// run immediately if on main thread
+	if IsMainThread() {
+		return fn()
+	}
+
+	// prepare result
+	var res T
+	var err error
+	done := make(chan struct{})
+
+	// defer function
+	Defer(func() {
+		defer close(done)
+		res, err = fn()
+	})
+
+	// await result
+	<-done
+
+	return res, err
+}
@@ -0,0 +1,31 @@
+// Package dsp provides lock-free parameters, line~-style ramps and smoothed
+// parameters for objects implementing max.ProcessingInstance. Values are set
+// from Handle, which may run on any thread, and read from Process on the audio
+// thread without locking or allocating.
+package dsp
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// Param is a float64 value that can be set and read concurrently without
+// locking.
+type Param struct {
+	bits uint64
+}
+
+// NewParam will create a parameter with the provided initial value.
+func NewParam(value float64) *Param {
+	return &Param{bits: math.Float64bits(value)}
+}
+
+// Set will set the value.
+func (p *Param) Set(value float64) {
+	atomic.StoreUint64(&p.bits, math.Float64bits(value))
+}
+
+// Get will return the value.
+func (p *Param) Get() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&p.bits))
+}
@@ -0,0 +1,159 @@
+package dsp
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// Curve describes the shape of a ramp.
+type Curve int
+
+// The available curves.
+const (
+	// Linear ramps change the value by a constant amount per sample like
+	// line~.
+	Linear Curve = iota
+
+	// Exponential ramps change the value by a constant factor per sample,
+	// which is perceived as linear for gain and frequency values. Ramps
+	// between values of different signs or from and to zero fall back to
+	// linear ramps.
+	Exponential
+)
+
+type rampCommand struct {
+	target float64
+	time   float64
+	jump   bool
+}
+
+// Ramp generates a signal that moves towards a target value over a specified
+// time like line~. Targets may be set from any thread while the signal is
+// generated on the audio thread.
+type Ramp struct {
+	curve      Curve
+	sampleRate float64
+	command    atomic.Value
+	last       *rampCommand
+	value      float64
+	target     float64
+	step       float64
+	multiply   bool
+	remaining  int
+}
+
+// NewRamp will create a ramp with the provided curve, sample rate and initial
+// value.
+func NewRamp(curve Curve, sampleRate, value float64) *Ramp {
+	return &Ramp{
+		curve:      curve,
+		sampleRate: sampleRate,
+		value:      value,
+		target:     value,
+	}
+}
+
+// Go will start a ramp from the current value to the target value over the
+// specified time in milliseconds. It may be called from any thread.
+func (r *Ramp) Go(target, ms float64) {
+	r.command.Store(&rampCommand{target: target, time: ms})
+}
+
+// Jump will immediately set the value and stop the current ramp. It may be
+// called from any thread.
+func (r *Ramp) Jump(value float64) {
+	r.command.Store(&rampCommand{target: value, jump: true})
+}
+
+// SetSampleRate will set the sample rate used to convert ramp times. It must be
+// called on the audio thread.
+func (r *Ramp) SetSampleRate(sampleRate float64) {
+	r.sampleRate = sampleRate
+}
+
+// Value will return the current value. It must be called on the audio thread.
+func (r *Ramp) Value() float64 {
+	return r.value
+}
+
+// Active will return whether the ramp is moving. It must be called on the
+// audio thread.
+func (r *Ramp) Active() bool {
+	return r.remaining > 0
+}
+
+// Next will advance the ramp by one sample and return the value. It must be
+// called on the audio thread.
+func (r *Ramp) Next() float64 {
+	// apply command
+	r.update()
+
+	// check if done
+	if r.remaining == 0 {
+		return r.value
+	}
+
+	// advance value
+	r.remaining--
+	if r.remaining == 0 {
+		r.value = r.target
+	} else if r.multiply {
+		r.value *= r.step
+	} else {
+		r.value += r.step
+	}
+
+	return r.value
+}
+
+// Process will fill the provided buffer with the next values of the ramp. It
+// must be called on the audio thread.
+func (r *Ramp) Process(out []float64) {
+	// apply command
+	r.update()
+
+	// fill constant value
+	if r.remaining == 0 {
+		for i := range out {
+			out[i] = r.value
+		}
+		return
+	}
+
+	// fill ramp
+	for i := range out {
+		out[i] = r.Next()
+	}
+}
+
+func (r *Ramp) update() {
+	// get command
+	cmd, _ := r.command.Load().(*rampCommand)
+	if cmd == nil || cmd == r.last {
+		return
+	}
+	r.last = cmd
+
+	// compute samples
+	samples := int(math.Round(cmd.time * r.sampleRate / 1000))
+
+	// jump if requested or immediate
+	if cmd.jump || samples <= 0 {
+		r.value = cmd.target
+		r.target = cmd.target
+		r.remaining = 0
+		return
+	}
+
+	// set target
+	r.target = cmd.target
+	r.remaining = samples
+
+	// compute step
+	r.multiply = r.curve == Exponential && r.value*r.target > 0
+	if r.multiply {
+		r.step = math.Pow(r.target/r.value, 1/float64(samples))
+	} else {
+		r.step = (r.target - r.value) / float64(samples)
+	}
+}
@@ -0,0 +1,156 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRamp(t *testing.T) {
+	for _, item := range []struct {
+		name   string
+		curve  Curve
+		start  float64
+		target float64
+		ms     float64
+		values []float64
+	}{
+		{"LinearUp", Linear, 0, 1, 5, []float64{0.2, 0.4, 0.6, 0.8, 1}},
+		{"LinearDown", Linear, 1, -1, 4, []float64{0.5, 0, -0.5, -1}},
+		{"ExponentialUp", Exponential, 1, 16, 4, []float64{2, 4, 8, 16}},
+		{"ExponentialDown", Exponential, -8, -1, 3, []float64{-4, -2, -1}},
+		{"ExponentialFromZero", Exponential, 0, 1, 4, []float64{0.25, 0.5, 0.75, 1}},
+		{"ExponentialToZero", Exponential, 1, 0, 4, []float64{0.75, 0.5, 0.25, 0}},
+		{"ExponentialSignChange", Exponential, -1, 1, 4, []float64{-0.5, 0, 0.5, 1}},
+		{"Rounding", Linear, 0, 1, 1.6, []float64{0.5, 1}},
+		{"Immediate", Linear, 0, 1, 0, []float64{1}},
+		{"Negative", Linear, 0, 1, -5, []float64{1}},
+	} {
+		// sample rate of 1000 for one sample per millisecond
+		ramp := NewRamp(item.curve, 1000, item.start)
+		ramp.Go(item.target, item.ms)
+
+		// check values and step count
+		for i, exp := range item.values {
+			act := ramp.Next()
+			if math.Abs(act-exp) > 1e-9 {
+				t.Errorf("%s: sample %d: expected %f, got %f", item.name, i, exp, act)
+			}
+			if ramp.Active() != (i < len(item.values)-1) {
+				t.Errorf("%s: sample %d: unexpected active %t", item.name, i, ramp.Active())
+			}
+		}
+
+		// check endpoint
+		if ramp.Value() != item.target || ramp.Next() != item.target {
+			t.Errorf("%s: expected exact endpoint %f, got %f", item.name, item.target, ramp.Value())
+		}
+	}
+}
+
+func TestRampSampleRate(t *testing.T) {
+	ramp := NewRamp(Linear, 44100, 0)
+	ramp.Go(1, 10)
+
+	// count steps
+	var steps int
+	for ramp.Next() != 1 {
+		steps++
+	}
+	steps++
+
+	// check count
+	if steps != 441 {
+		t.Errorf("expected 441 steps, got %d", steps)
+	}
+
+	// check rate change
+	ramp.SetSampleRate(48000)
+	ramp.Go(0, 10)
+	steps = 0
+	for ramp.Next() != 0 {
+		steps++
+	}
+	steps++
+	if steps != 480 {
+		t.Errorf("expected 480 steps, got %d", steps)
+	}
+}
+
+func TestRampJump(t *testing.T) {
+	ramp := NewRamp(Linear, 1000, 0)
+
+	// jump before processing
+	ramp.Jump(5)
+	if ramp.Next() != 5 || ramp.Active() {
+		t.Errorf("expected 5, got %f", ramp.Value())
+	}
+
+	// jump during ramp
+	ramp.Go(10, 10)
+	ramp.Next()
+	ramp.Next()
+	if !ramp.Active() {
+		t.Error("expected active ramp")
+	}
+	ramp.Jump(-3)
+	if ramp.Next() != -3 || ramp.Active() {
+		t.Errorf("expected -3, got %f", ramp.Value())
+	}
+	if ramp.Next() != -3 {
+		t.Errorf("expected -3, got %f", ramp.Value())
+	}
+
+	// ramp from jumped value
+	ramp.Go(-1, 2)
+	if v := ramp.Next(); v != -2 {
+		t.Errorf("expected -2, got %f", v)
+	}
+}
+
+func TestRampRetarget(t *testing.T) {
+	ramp := NewRamp(Linear, 1000, 0)
+
+	// start ramp
+	ramp.Go(1, 4)
+	ramp.Next()
+	ramp.Next()
+
+	// change target from current value
+	ramp.Go(0, 2)
+	for i, exp := range []float64{0.25, 0} {
+		if v := ramp.Next(); math.Abs(v-exp) > 1e-9 {
+			t.Errorf("sample %d: expected %f, got %f", i, exp, v)
+		}
+	}
+
+	// same command is applied once
+	if ramp.Active() || ramp.Next() != 0 {
+		t.Errorf("expected idle ramp at 0, got %f", ramp.Value())
+	}
+}
+
+func TestRampProcess(t *testing.T) {
+	ramp := NewRamp(Linear, 1000, 2)
+
+	// fill constant value
+	out := make([]float64, 3)
+	ramp.Process(out)
+	for i, v := range out {
+		if v != 2 {
+			t.Errorf("sample %d: expected 2, got %f", i, v)
+		}
+	}
+
+	// fill ramp across buffers
+	ramp.Go(6, 4)
+	var values []float64
+	for i := 0; i < 2; i++ {
+		ramp.Process(out)
+		values = append(values, out...)
+	}
+	for i, exp := range []float64{3, 4, 5, 6, 6, 6} {
+		if math.Abs(values[i]-exp) > 1e-9 {
+			t.Errorf("sample %d: expected %f, got %f", i, exp, values[i])
+		}
+	}
+}
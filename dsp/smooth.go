@@ -0,0 +1,97 @@
+package dsp
+
+import "math"
+
+// Smoother is a one-pole lowpass filter that smooths control values to avoid
+// zipper noise. The time is the duration in milliseconds after which the output
+// has covered about 63% of the distance to the target.
+type Smoother struct {
+	coeff float64
+	value float64
+}
+
+// NewSmoother will create a smoother with the provided sample rate, time and
+// initial value.
+func NewSmoother(sampleRate, ms, value float64) *Smoother {
+	s := &Smoother{value: value}
+	s.SetTime(sampleRate, ms)
+	return s
+}
+
+// SetTime will set the smoothing time in milliseconds for the provided sample
+// rate. A time of zero disables smoothing.
+func (s *Smoother) SetTime(sampleRate, ms float64) {
+	// check time
+	samples := ms * sampleRate / 1000
+	if samples <= 0 {
+		s.coeff = 0
+		return
+	}
+
+	// compute coefficient
+	s.coeff = math.Exp(-1 / samples)
+}
+
+// Reset will set the value immediately.
+func (s *Smoother) Reset(value float64) {
+	s.value = value
+}
+
+// Value will return the current value.
+func (s *Smoother) Value() float64 {
+	return s.value
+}
+
+// Next will advance the smoother by one sample towards the target and return
+// the value.
+func (s *Smoother) Next(target float64) float64 {
+	s.value = target + s.coeff*(s.value-target)
+	return s.value
+}
+
+// Process will fill the provided buffer with the next values of the smoother.
+func (s *Smoother) Process(out []float64, target float64) {
+	for i := range out {
+		out[i] = s.Next(target)
+	}
+}
+
+// SmoothedParam combines a Param with a Smoother. The value may be set from any
+// thread while the smoothed signal is generated on the audio thread.
+type SmoothedParam struct {
+	Param
+	smoother Smoother
+}
+
+// NewSmoothedParam will create a smoothed parameter with the provided sample
+// rate, smoothing time in milliseconds and initial value.
+func NewSmoothedParam(sampleRate, ms, value float64) *SmoothedParam {
+	p := &SmoothedParam{}
+	p.Set(value)
+	p.smoother.Reset(value)
+	p.smoother.SetTime(sampleRate, ms)
+	return p
+}
+
+// SetTime will set the smoothing time. It must be called on the audio thread.
+func (p *SmoothedParam) SetTime(sampleRate, ms float64) {
+	p.smoother.SetTime(sampleRate, ms)
+}
+
+// Value will return the current smoothed value. It must be called on the audio
+// thread.
+func (p *SmoothedParam) Value() float64 {
+	return p.smoother.Value()
+}
+
+// Next will advance the smoothed value by one sample and return it. It must be
+// called on the audio thread.
+func (p *SmoothedParam) Next() float64 {
+	return p.smoother.Next(p.Get())
+}
+
+// Process will fill the provided buffer with the next smoothed values. It must
+// be called on the audio thread.
+func (p *SmoothedParam) Process(out []float64) {
+	p.smoother.Process(out, p.Get())
+}
@@ -0,0 +1,98 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParam(t *testing.T) {
+	p := NewParam(1.5)
+	if p.Get() != 1.5 {
+		t.Errorf("expected 1.5, got %f", p.Get())
+	}
+
+	p.Set(-2)
+	if p.Get() != -2 {
+		t.Errorf("expected -2, got %f", p.Get())
+	}
+}
+
+func TestSmootherTimeConstant(t *testing.T) {
+	for _, item := range []struct {
+		rate float64
+		ms   float64
+	}{
+		{1000, 10},
+		{44100, 10},
+		{48000, 20},
+	} {
+		// advance by time constant
+		s := NewSmoother(item.rate, item.ms, 0)
+		n := int(math.Round(item.rate * item.ms / 1000))
+		for i := 0; i < n; i++ {
+			s.Next(1)
+		}
+
+		// check about 63% covered
+		exp := 1 - math.Exp(-1)
+		if math.Abs(s.Value()-exp) > 1e-6 {
+			t.Errorf("%f/%f: expected %f, got %f", item.rate, item.ms, exp, s.Value())
+		}
+
+		// advance by four more time constants
+		for i := 0; i < n*4; i++ {
+			s.Next(1)
+		}
+
+		// check about 99% covered
+		exp = 1 - math.Exp(-5)
+		if math.Abs(s.Value()-exp) > 1e-6 {
+			t.Errorf("%f/%f: expected %f, got %f", item.rate, item.ms, exp, s.Value())
+		}
+	}
+}
+
+func TestSmootherDisabled(t *testing.T) {
+	s := NewSmoother(44100, 0, 0)
+	if v := s.Next(3); v != 3 {
+		t.Errorf("expected 3, got %f", v)
+	}
+
+	// change time
+	s.SetTime(1000, 1)
+	if v := s.Next(0); math.Abs(v-3*math.Exp(-1)) > 1e-9 {
+		t.Errorf("expected %f, got %f", 3*math.Exp(-1), v)
+	}
+}
+
+func TestSmootherReset(t *testing.T) {
+	s := NewSmoother(1000, 10, 0)
+	s.Next(1)
+
+	// reset value
+	s.Reset(5)
+	if s.Value() != 5 || s.Next(5) != 5 {
+		t.Errorf("expected 5, got %f", s.Value())
+	}
+}
+
+func TestSmoothedParam(t *testing.T) {
+	p := NewSmoothedParam(1000, 10, 1)
+	if p.Value() != 1 || p.Next() != 1 {
+		t.Errorf("expected 1, got %f", p.Value())
+	}
+
+	// set target and process
+	p.Set(0)
+	out := make([]float64, 10)
+	p.Process(out)
+	for i, v := range out {
+		exp := math.Exp(-float64(i+1) / 10)
+		if math.Abs(v-exp) > 1e-9 {
+			t.Errorf("sample %d: expected %f, got %f", i, exp, v)
+		}
+	}
+	if p.Value() != out[9] {
+		t.Errorf("expected %f, got %f", out[9], p.Value())
+	}
+}
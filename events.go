@@ -0,0 +1,210 @@
+package max
+
+// #include "max.h"
+import "C"
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// ProcessEvent is a control event that is delivered to the audio thread at a
+// specific scheduler time.
+type ProcessEvent struct {
+	// The scheduler time in milliseconds.
+	Time float64
+
+	// The sample offset within the current vector.
+	Offset int
+
+	// The event payload.
+	Inlet int
+	Msg   string
+	Data  []Atom
+}
+
+// EventQueueSize is the number of events that can be pending per object.
+const EventQueueSize = 256
+
+// Now will return the current logical time of the Max scheduler in
+// milliseconds.
+func Now() float64 {
+	var now C.double
+	C.clock_getftime(&now)
+	return float64(now)
+}
+
+// Post will queue the provided event for delivery to the audio thread. If the
+// time is zero the event is stamped with the current scheduler time. The event
+// is delivered with the first vector that ends after its time and its offset is
+// set to the position of the time within that vector. Events due in the past
+// are delivered with an offset of zero. Post may be called from any thread
+// and does not lock. It returns false if the queue is full.
+func (o *Object) Post(evt ProcessEvent) bool {
+	// stamp event
+	if evt.Time == 0 {
+		evt.Time = Now()
+	}
+
+	// add event
+	ok := o.eventQueue().push(evt)
+	if !ok {
+		Error("dropped event due to full event queue")
+	}
+
+	return ok
+}
+
+// Events will return the events due in the current vector ordered by their
+// offsets. It may only be called from the process callback.
+func (o *Object) Events() []ProcessEvent {
+	// check queue
+	if atomic.LoadUint32(&o.eventsReady) == 0 {
+		return nil
+	}
+
+	return o.events.due
+}
+
+func (o *Object) eventQueue() *eventQueue {
+	// create queue on first use
+	o.eventsOnce.Do(func() {
+		o.events = newEventQueue(EventQueueSize)
+		atomic.StoreUint32(&o.eventsReady, 1)
+	})
+
+	return o.events
+}
+
+func (o *Object) collectEvents(samples int) {
+	// check queue
+	if atomic.LoadUint32(&o.eventsReady) == 0 {
+		return
+	}
+
+	// collect events
+	o.events.collect(samples)
+}
+
+type eventSlot struct {
+	seq uint64
+	evt ProcessEvent
+}
+
+// eventQueue is a bounded multi-producer single-consumer queue that does not
+// lock or allocate.
+type eventQueue struct {
+	head    uint64
+	tail    uint64
+	mask    uint64
+	slots   []eventSlot
+	pending []ProcessEvent
+	due     []ProcessEvent
+}
+
+func newEventQueue(size int) *eventQueue {
+	// prepare slots
+	slots := make([]eventSlot, size)
+	for i := range slots {
+		slots[i].seq = uint64(i)
+	}
+
+	return &eventQueue{
+		mask:    uint64(size - 1),
+		slots:   slots,
+		pending: make([]ProcessEvent, 0, size),
+		due:     make([]ProcessEvent, 0, size),
+	}
+}
+
+func (q *eventQueue) push(evt ProcessEvent) bool {
+	// claim slot
+	pos := atomic.LoadUint64(&q.head)
+	for {
+		slot := &q.slots[pos&q.mask]
+		seq := atomic.LoadUint64(&slot.seq)
+		diff := int64(seq) - int64(pos)
+		if diff == 0 {
+			if atomic.CompareAndSwapUint64(&q.head, pos, pos+1) {
+				slot.evt = evt
+				atomic.StoreUint64(&slot.seq, pos+1)
+				return true
+			}
+		} else if diff < 0 {
+			return false
+		}
+		pos = atomic.LoadUint64(&q.head)
+	}
+}
+
+func (q *eventQueue) pop() (ProcessEvent, bool) {
+	// check slot
+	slot := &q.slots[q.tail&q.mask]
+	seq := atomic.LoadUint64(&slot.seq)
+	if int64(seq)-int64(q.tail+1) < 0 {
+		return ProcessEvent{}, false
+	}
+
+	// take event
+	evt := slot.evt
+	slot.evt = ProcessEvent{}
+	atomic.StoreUint64(&slot.seq, q.tail+q.mask+1)
+	q.tail++
+
+	return evt, true
+}
+
+func (q *eventQueue) collect(samples int) {
+	// reset due events
+	for i := range q.due {
+		q.due[i] = ProcessEvent{}
+	}
+	q.due = q.due[:0]
+
+	// move queued events to pending
+	for len(q.pending) < cap(q.pending) {
+		evt, ok := q.pop()
+		if !ok {
+			break
+		}
+		q.pending = append(q.pending, evt)
+	}
+
+	// check pending
+	if len(q.pending) == 0 {
+		return
+	}
+
+	// get vector window
+	start := Now()
+	rate := float64(C.sys_getsr()) / 1000
+	end := math.Inf(1)
+	if rate > 0 {
+		end = start + float64(samples)/rate
+	}
+
+	// split pending events
+	keep := q.pending[:0]
+	for _, evt := range q.pending {
+		if evt.Time >= end {
+			keep = append(keep, evt)
+			continue
+		}
+		evt.Offset = 0
+		if rate > 0 && evt.Time > start {
+			evt.Offset = int(math.Min(float64(samples-1), math.Floor((evt.Time-start)*rate)))
+		}
+		q.due = append(q.due, evt)
+	}
+	for i := len(keep); i < len(q.pending); i++ {
+		q.pending[i] = ProcessEvent{}
+	}
+	q.pending = keep
+
+	// sort due events by offset using an insertion sort to avoid allocations
+	for i := 1; i < len(q.due); i++ {
+		for j := i; j > 0 && q.due[j].Offset < q.due[j-1].Offset; j-- {
+			q.due[j], q.due[j-1] = q.due[j-1], q.due[j]
+		}
+	}
+}
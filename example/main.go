@@ -51,6 +51,9 @@ func (i *instance) Init(obj *max.Object, args []max.Atom) bool {
 	i.floatOut = obj.Outlet(max.Float, "float")
 	i.bangOut = obj.Outlet(max.Bang, "bang")
 
+	// declare messages
+	obj.Message("setrange", max.Float, max.Float).Label("min", "max")
+
 	// bang second outlet from a timer
 	if !i.bench {
 		// create timer
@@ -0,0 +1,170 @@
+package max
+
+// #include "max.h"
+import "C"
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"unsafe"
+)
+
+// ErrFileNotFound is returned if a file cannot be located.
+var ErrFileNotFound = errors.New("file not found")
+
+// LocateFile will resolve the named file using the Max search path. The
+// optional four-character file types (e.g. "JSON" or "TEXT") restrict the
+// search to matching files. The absolute system path of the file is returned.
+// Use Object.LocateFile to also search the directory of the objects patcher.
+func LocateFile(name string, types ...string) (string, error) {
+	// prepare types
+	list := fourccs(types)
+
+	// locate file
+	path := C.maxgo_locate(C.CString(name), fourccPtr(list), C.short(len(list))) // string freed by receiver
+	if path == nil {
+		return "", ErrFileNotFound
+	}
+
+	return takeString(path), nil
+}
+
+// LocateFile will resolve the named file in the directory of the objects
+// patcher first and then using the Max search path like the global LocateFile.
+// The file types only restrict the search path lookup. It must be called on
+// the Max main thread.
+func (o *Object) LocateFile(name string, types ...string) (string, error) {
+	// check patcher directory
+	if dir := o.Patcher().Dir(); dir != "" && !filepath.IsAbs(name) {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+
+	return LocateFile(name, types...)
+}
+
+// OpenDialog will show a dialog to select a file to open. The optional
+// four-character file types restrict the selectable files. It returns the
+// absolute system path of the selected file and false if the dialog has been
+// cancelled. It must be called on the Max main thread.
+func OpenDialog(prompt string, types ...string) (string, bool) {
+	// prepare types
+	list := fourccs(types)
+
+	// prepare prompt
+	var cPrompt *C.char
+	if prompt != "" {
+		cPrompt = C.CString(prompt) // string freed by receiver
+	}
+
+	// show dialog
+	path := C.maxgo_open_dialog(cPrompt, fourccPtr(list), C.short(len(list)))
+	if path == nil {
+		return "", false
+	}
+
+	return takeString(path), true
+}
+
+// SaveDialog will show a dialog to select a file to save using the provided
+// default file name. The optional four-character file types are offered as
+// formats. It returns the absolute system path of the selected file and false
+// if the dialog has been cancelled. It must be called on the Max main thread.
+func SaveDialog(prompt, filename string, types ...string) (string, bool) {
+	// prepare types
+	list := fourccs(types)
+
+	// prepare prompt
+	var cPrompt *C.char
+	if prompt != "" {
+		cPrompt = C.CString(prompt) // string freed by receiver
+	}
+
+	// show dialog
+	path := C.maxgo_save_dialog(cPrompt, C.CString(filename), fourccPtr(list), C.short(len(list))) // strings freed by receiver
+	if path == nil {
+		return "", false
+	}
+
+	return takeString(path), true
+}
+
+// File will return the absolute system path of the file the patcher has been
+// loaded from. For subpatchers, the file of the closest parent patcher that has
+// been loaded from a file is returned, which is the abstraction or the
+// top-level patcher. It returns an empty string if the patcher has not been
+// saved yet.
+func (p *Patcher) File() string {
+	// check validity
+	if !p.Valid() {
+		return ""
+	}
+
+	// find file path walking up the parents
+	var sym *C.t_symbol
+	for ptr := p.ptr; ptr != nil; ptr = C.jpatcher_get_parentpatcher(ptr) {
+		sym = C.jpatcher_get_filepath(ptr)
+		if sym != nil && C.GoString(sym.s_name) != "" {
+			break
+		}
+	}
+	if sym == nil || C.GoString(sym.s_name) == "" {
+		return ""
+	}
+
+	// conform path
+	path := C.maxgo_conform(sym)
+	if path == nil {
+		return ""
+	}
+
+	return takeString(path)
+}
+
+// Dir will return the directory of the file returned by File. It returns an
+// empty string if the patcher has not been saved yet.
+func (p *Patcher) Dir() string {
+	// get file
+	file := p.File()
+	if file == "" {
+		return ""
+	}
+
+	return filepath.Dir(file)
+}
+
+func fourccs(types []string) []C.t_fourcc {
+	// convert types
+	list := make([]C.t_fourcc, 0, len(types))
+	for _, typ := range types {
+		var code uint32
+		for i := 0; i < 4; i++ {
+			c := byte(' ')
+			if i < len(typ) {
+				c = typ[i]
+			}
+			code = code<<8 | uint32(c)
+		}
+		list = append(list, C.t_fourcc(code))
+	}
+
+	return list
+}
+
+func fourccPtr(list []C.t_fourcc) *C.t_fourcc {
+	if len(list) == 0 {
+		return nil
+	}
+
+	return &list[0]
+}
+
+func takeString(str *C.char) string {
+	// copy and free string
+	defer C.free(unsafe.Pointer(str))
+
+	return C.GoString(str)
+}
@@ -0,0 +1,109 @@
+// Package block implements the rebuffering of audio vectors into fixed size
+// overlapping blocks. It does not depend on cgo so that it can be tested
+// without linking the Max API.
+package block
+
+// Processor processes a block of input samples into a block of output samples.
+type Processor interface {
+	ProcessBlock(input, output [][]float64)
+}
+
+// Buffer rebuffers audio into fixed size blocks. All buffers are allocated
+// upfront. The size and hop must be checked by the caller.
+type Buffer struct {
+	size     int
+	hop      int
+	inRing   [][]float64
+	outAcc   [][]float64
+	inFrame  [][]float64
+	outFrame [][]float64
+	write    int
+	read     int
+	count    int
+}
+
+// New will create a buffer for the provided block size, hop and channels.
+func New(size, hop, inputs, outputs int) *Buffer {
+	return &Buffer{
+		size:     size,
+		hop:      hop,
+		inRing:   makeBuffers(inputs, size),
+		outAcc:   makeBuffers(outputs, size),
+		inFrame:  makeBuffers(inputs, size),
+		outFrame: makeBuffers(outputs, size),
+	}
+}
+
+// Process will rebuffer the provided vectors and call the processor every hop
+// samples. The output is delayed by size samples.
+func (b *Buffer) Process(pro Processor, input, output [][]float64) {
+	// check channels
+	if len(input) != len(b.inRing) || len(output) != len(b.outAcc) {
+		return
+	}
+
+	// process samples
+	for j, n := 0, vectorSize(input, output); j < n; j++ {
+		// emit output
+		for i, ch := range output {
+			ch[j] = b.outAcc[i][b.read]
+			b.outAcc[i][b.read] = 0
+		}
+		b.read = (b.read + 1) % b.size
+
+		// store input
+		for i, ch := range input {
+			b.inRing[i][b.write] = ch[j]
+		}
+		b.write = (b.write + 1) % b.size
+
+		// check hop
+		b.count++
+		if b.count < b.hop {
+			continue
+		}
+		b.count = 0
+
+		// prepare frames, the oldest sample is at the write position
+		for i, ring := range b.inRing {
+			n := copy(b.inFrame[i], ring[b.write:])
+			copy(b.inFrame[i][n:], ring[:b.write])
+		}
+		for _, frame := range b.outFrame {
+			for k := range frame {
+				frame[k] = 0
+			}
+		}
+
+		// process block
+		pro.ProcessBlock(b.inFrame, b.outFrame)
+
+		// overlap-add output
+		for i, frame := range b.outFrame {
+			for k, s := range frame {
+				b.outAcc[i][(b.read+k)%b.size] += s
+			}
+		}
+	}
+}
+
+func vectorSize(input, output [][]float64) int {
+	// get size from first channel
+	if len(input) > 0 {
+		return len(input[0])
+	} else if len(output) > 0 {
+		return len(output[0])
+	}
+
+	return 0
+}
+
+func makeBuffers(channels, size int) [][]float64 {
+	// allocate buffers
+	list := make([][]float64, channels)
+	for i := range list {
+		list[i] = make([]float64, size)
+	}
+
+	return list
+}
@@ -0,0 +1,81 @@
+package block
+
+import (
+	"math"
+	"testing"
+)
+
+type identity struct {
+	gain float64
+}
+
+func (p *identity) ProcessBlock(input, output [][]float64) {
+	for i, ch := range input {
+		for k, s := range ch {
+			output[i][k] = s * p.gain
+		}
+	}
+}
+
+func TestBuffer(t *testing.T) {
+	for _, item := range []struct {
+		size   int
+		hop    int
+		vector int
+	}{
+		{100, 100, 64},
+		{100, 50, 64},
+		{100, 25, 64},
+		{64, 32, 64},
+		{100, 50, 1},
+		{100, 50, 128},
+		{100, 50, 1000},
+	} {
+		// prepare buffer, the identity applies a rectangular window that sums
+		// to unity gain when overlap-added
+		buf := New(item.size, item.hop, 2, 2)
+		pro := &identity{gain: float64(item.hop) / float64(item.size)}
+
+		// prepare signal
+		total := item.size*4 + item.vector*3 + 7
+		signal := make([][]float64, 2)
+		for i := range signal {
+			signal[i] = make([]float64, total)
+			for j := range signal[i] {
+				signal[i][j] = math.Sin(float64(j)*0.1+float64(i)) + float64(j%7)
+			}
+		}
+
+		// process vectors
+		result := make([][]float64, 2)
+		for j := 0; j+item.vector <= total; j += item.vector {
+			input := [][]float64{signal[0][j : j+item.vector], signal[1][j : j+item.vector]}
+			output := [][]float64{make([]float64, item.vector), make([]float64, item.vector)}
+			buf.Process(pro, input, output)
+			result[0] = append(result[0], output[0]...)
+			result[1] = append(result[1], output[1]...)
+		}
+
+		// check delayed output
+		for i, ch := range result {
+			for j, s := range ch {
+				exp := 0.0
+				if j >= item.size {
+					exp = signal[i][j-item.size]
+				}
+				if math.Abs(s-exp) > 1e-9 {
+					t.Fatalf("%d/%d/%d: channel %d sample %d: expected %f, got %f", item.size, item.hop, item.vector, i, j, exp, s)
+				}
+			}
+		}
+	}
+}
+
+func TestBufferChannelMismatch(t *testing.T) {
+	buf := New(4, 2, 1, 1)
+	output := [][]float64{{1, 2}, {3, 4}}
+	buf.Process(&identity{gain: 1}, [][]float64{{1, 2}}, output)
+	if output[0][0] != 1 || output[1][1] != 4 {
+		t.Errorf("unexpected output %v", output)
+	}
+}
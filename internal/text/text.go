@@ -0,0 +1,227 @@
+// Package text implements the parsing and formatting of Max message text. It
+// does not depend on cgo so that it can be tested without linking the Max API.
+package text
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Kind is the kind of a token.
+type Kind int
+
+// The available kinds.
+const (
+	Int Kind = iota
+	Float
+	Symbol
+	Separator
+	Dollar
+	DollarSymbol
+	Object
+	Unknown
+)
+
+// Token is a single parsed or formatted atom. Ints and dollar arguments are
+// stored in Int, floats in Float and symbols, separators and dollar symbols in
+// Symbol.
+type Token struct {
+	Kind   Kind
+	Int    int64
+	Float  float64
+	Symbol string
+}
+
+// Parse will parse the provided text into tokens following the rules of Max
+// message boxes and text files. Integers and floats are parsed as numbers,
+// double-quoted text and unquoted words as symbols, commas and semicolons as
+// separators and $1-style arguments as dollar tokens. Backslashes escape the
+// following character.
+func Parse(text string) ([]Token, error) {
+	// prepare state
+	var tokens []Token
+	var token strings.Builder
+	var inToken, quoted, escaped, literal bool
+
+	// flush emits the current token
+	flush := func() {
+		if !inToken {
+			return
+		}
+		tokens = append(tokens, parseToken(token.String(), literal))
+		token.Reset()
+		inToken, literal = false, false
+	}
+
+	// parse text
+	for _, r := range text {
+		switch {
+		case escaped:
+			token.WriteRune(r)
+			inToken, literal, escaped = true, true, false
+		case r == '\\':
+			escaped = true
+		case quoted:
+			if r == '"' {
+				quoted = false
+			} else {
+				token.WriteRune(r)
+			}
+		case r == '"':
+			quoted, inToken, literal = true, true, true
+		case r == ',' || r == ';':
+			flush()
+			tokens = append(tokens, Token{Kind: Separator, Symbol: string(r)})
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			token.WriteRune(r)
+			inToken = true
+		}
+	}
+
+	// check state
+	if quoted {
+		return nil, errors.New("unterminated quote")
+	} else if escaped {
+		return nil, errors.New("unterminated escape")
+	}
+
+	// flush last token
+	flush()
+
+	return tokens, nil
+}
+
+func parseToken(token string, literal bool) Token {
+	// quoted and escaped tokens are always symbols
+	if literal {
+		return Token{Kind: Symbol, Symbol: token}
+	}
+
+	// parse numbers
+	if isNumeric(token) {
+		if n, err := strconv.ParseInt(token, 10, 64); err == nil {
+			return Token{Kind: Int, Int: n}
+		}
+		if f, err := strconv.ParseFloat(token, 64); err == nil {
+			return Token{Kind: Float, Float: f}
+		}
+	}
+
+	// parse dollar arguments
+	if strings.Contains(token, "$") {
+		if len(token) > 1 && token[0] == '$' {
+			if n, err := strconv.ParseInt(token[1:], 10, 64); err == nil && n >= 0 {
+				return Token{Kind: Dollar, Int: n}
+			}
+		}
+		return Token{Kind: DollarSymbol, Symbol: token}
+	}
+
+	return Token{Kind: Symbol, Symbol: token}
+}
+
+func isNumeric(token string) bool {
+	// check characters
+	var digits bool
+	for i, r := range token {
+		switch {
+		case r >= '0' && r <= '9':
+			digits = true
+		case r == '-' || r == '+':
+			if i != 0 && token[i-1] != 'e' && token[i-1] != 'E' {
+				return false
+			}
+		case r == '.' || r == 'e' || r == 'E':
+		default:
+			return false
+		}
+	}
+
+	return digits
+}
+
+// Format will format the provided tokens as text that can be parsed by Parse
+// and Max. Symbols are quoted or escaped if necessary and floats always contain
+// a decimal point. Infinite and NaN floats have no textual representation and
+// are formatted as "inf", "-inf" and "nan" like Max does, which are parsed back
+// as symbols.
+func Format(tokens []Token) string {
+	// prepare builder
+	var b strings.Builder
+
+	// format tokens
+	for i, token := range tokens {
+		// add space unless before separator
+		if i > 0 && token.Kind != Separator {
+			b.WriteByte(' ')
+		}
+
+		// format token
+		switch token.Kind {
+		case Int:
+			b.WriteString(strconv.FormatInt(token.Int, 10))
+		case Float:
+			b.WriteString(formatFloat(token.Float))
+		case Symbol:
+			b.WriteString(formatSymbol(token.Symbol))
+		case Separator, DollarSymbol:
+			b.WriteString(token.Symbol)
+		case Dollar:
+			b.WriteString("$" + strconv.FormatInt(token.Int, 10))
+		case Object:
+			b.WriteString("<object>")
+		default:
+			b.WriteString("<unknown>")
+		}
+	}
+
+	return b.String()
+}
+
+func formatFloat(f float64) string {
+	// handle special values
+	switch {
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	case math.IsNaN(f):
+		return "nan"
+	}
+
+	// format number
+	str := strconv.FormatFloat(f, 'g', -1, 64)
+
+	// ensure decimal point
+	if !strings.ContainsAny(str, ".eE") {
+		str += "."
+	}
+
+	return str
+}
+
+func formatSymbol(sym string) string {
+	// quote empty symbols and symbols that would be parsed differently
+	needsQuotes := sym == "" || parseToken(sym, false).Kind != Symbol
+	for _, r := range sym {
+		if unicode.IsSpace(r) || r == ',' || r == ';' {
+			needsQuotes = true
+		}
+	}
+
+	// escape backslashes and quotes
+	sym = strings.ReplaceAll(sym, `\`, `\\`)
+	sym = strings.ReplaceAll(sym, `"`, `\"`)
+
+	// add quotes
+	if needsQuotes {
+		return `"` + sym + `"`
+	}
+
+	return sym
+}
@@ -0,0 +1,117 @@
+package text
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func i(n int64) Token         { return Token{Kind: Int, Int: n} }
+func f(n float64) Token       { return Token{Kind: Float, Float: n} }
+func s(str string) Token      { return Token{Kind: Symbol, Symbol: str} }
+func sep(r rune) Token        { return Token{Kind: Separator, Symbol: string(r)} }
+func d(n int64) Token         { return Token{Kind: Dollar, Int: n} }
+func ds(str string) Token     { return Token{Kind: DollarSymbol, Symbol: str} }
+func list(t ...Token) []Token { return t }
+
+func TestParse(t *testing.T) {
+	for _, item := range []struct {
+		text   string
+		tokens []Token
+	}{
+		{``, nil},
+		{`foo`, list(s("foo"))},
+		{`foo 1 2.5 -3`, list(s("foo"), i(1), f(2.5), i(-3))},
+		{`1.`, list(f(1))},
+		{`1`, list(i(1))},
+		{`1e3 -2.5e-2`, list(f(1000), f(-0.025))},
+		{`"1"`, list(s("1"))},
+		{`"foo bar"`, list(s("foo bar"))},
+		{`""`, list(s(""))},
+		{`foo\ bar`, list(s("foo bar"))},
+		{`a\"b`, list(s(`a"b`))},
+		{`a\\b`, list(s(`a\b`))},
+		{`\1`, list(s("1"))},
+		{`1, 2; 3`, list(i(1), sep(','), i(2), sep(';'), i(3))},
+		{`1,2;3`, list(i(1), sep(','), i(2), sep(';'), i(3))},
+		{`"a,b;c"`, list(s("a,b;c"))},
+		{`foo\,`, list(s("foo,"))},
+		{`$1 $23`, list(d(1), d(23))},
+		{`"$1"`, list(s("$1"))},
+		{`foo$1 $ $x`, list(ds("foo$1"), ds("$"), ds("$x"))},
+		{`1-2 - + . e`, list(s("1-2"), s("-"), s("+"), s("."), s("e"))},
+		{`inf -inf nan`, list(s("inf"), s("-inf"), s("nan"))},
+	} {
+		tokens, err := Parse(item.text)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", item.text, err)
+		} else if !reflect.DeepEqual(tokens, item.tokens) {
+			t.Errorf("%q: expected %v, got %v", item.text, item.tokens, tokens)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, text := range []string{`"foo`, `foo\`} {
+		_, err := Parse(text)
+		if err == nil {
+			t.Errorf("%q: expected error", text)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for _, item := range []struct {
+		tokens []Token
+		text   string
+	}{
+		{nil, ``},
+		{list(s("foo"), i(1), f(2.5), i(-3)), `foo 1 2.5 -3`},
+		{list(f(1)), `1.`},
+		{list(i(1)), `1`},
+		{list(f(1e21)), `1e+21`},
+		{list(s("1")), `"1"`},
+		{list(s("1.")), `"1."`},
+		{list(s("")), `""`},
+		{list(s("foo bar")), `"foo bar"`},
+		{list(s(`a"b`)), `a\"b`},
+		{list(s(`a\b`)), `a\\b`},
+		{list(s("a,b")), `"a,b"`},
+		{list(s("a;b")), `"a;b"`},
+		{list(i(1), sep(','), i(2), sep(';')), `1, 2;`},
+		{list(d(1)), `$1`},
+		{list(s("$1")), `"$1"`},
+		{list(ds("foo$1")), `foo$1`},
+		{list(s("foo$1")), `"foo$1"`},
+		{list(f(math.Inf(1)), f(math.Inf(-1)), f(math.NaN())), `inf -inf nan`},
+		{list(Token{Kind: Object}, Token{Kind: Unknown}), `<object> <unknown>`},
+	} {
+		text := Format(item.tokens)
+		if text != item.text {
+			t.Errorf("%v: expected %q, got %q", item.tokens, item.text, text)
+		}
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	for _, tokens := range [][]Token{
+		list(s("foo"), i(1), f(2.5), i(-3)),
+		list(f(1), i(1), f(-0.5), f(1e-7), f(1e21)),
+		list(s("1"), s("1."), s("-2"), s("1e3"), s("0.5")),
+		list(s("")),
+		list(s(""), s(""), s("foo")),
+		list(s(`"`), s(`\`), s(`a"b\c`), s(`"quoted"`)),
+		list(s("foo bar"), s(" "), s("\t"), s("a\nb")),
+		list(s(","), s(";"), s("a,b"), s("a;b")),
+		list(i(1), sep(','), s("x"), sep(';'), f(2)),
+		list(d(1), s("$1"), ds("foo$2"), s("foo$2"), s("$")),
+	} {
+		text := Format(tokens)
+		parsed, err := Parse(text)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", text, err)
+		} else if !reflect.DeepEqual(parsed, tokens) {
+			t.Errorf("%q: expected %v, got %v", text, tokens, parsed)
+		}
+	}
+}
@@ -14,6 +14,11 @@ void maxgo_error(char *str) {
   free(str);
 }
 
+void maxgo_object_post(t_object *obj, char *str) {
+  object_post(obj, "%s", str);
+  free(str);
+}
+
 void maxgo_alert(char *str) {
   ouchstring(str);
   free(str);
@@ -25,6 +30,24 @@ t_symbol *maxgo_gensym(char *str) {
   return sym;
 }
 
+/* Atoms */
+
+t_atom_long maxgo_atom_getlong(t_atom *atom) { return atom->a_w.w_long; }
+
+t_symbol *maxgo_atom_getsym(t_atom *atom) { return atom->a_w.w_sym; }
+
+void maxgo_atom_setdollar(t_atom *atom, t_atom_long n) { A_SETDOLLAR(atom, n); }
+
+void maxgo_atom_setdollsym(t_atom *atom, t_symbol *sym) { A_SETDOLLSYM(atom, sym); }
+
+void maxgo_atom_setsep(t_atom *atom, bool semi) {
+  if (semi) {
+    A_SETSEMI(atom);
+  } else {
+    A_SETCOMMA(atom);
+  }
+}
+
 /* Initialization */
 
 extern void ext_main(void *r) { maxgoMain(); }
@@ -35,12 +58,14 @@ static t_class *class = NULL;
 
 typedef struct {
   t_pxobject obj;
+  void *obex;
   long inlet;
   void **proxies;
   int num_proxies;
   int num_signals;
   unsigned long long ref;
   void *clock;
+  t_atom_long latency;
 } t_bridge;
 
 static void bridge_tick(void *ptr) {
@@ -121,9 +146,13 @@ static void *bridge_new(t_symbol *name, long argc, t_atom *argv) {
   // allocate proxy list
   bridge->proxies = (void **)getbytes(bridge->num_proxies * sizeof(void *));
 
-  // create proxies
+  // determine first proxy inlet, which follows the signal inlets or the
+  // default inlet
+  int first = bridge->num_signals > 0 ? bridge->num_signals : 1;
+
+  // create proxies from right to left
   for (int i = 0; i < bridge->num_proxies; i++) {
-    bridge->proxies[i] = proxy_new(&bridge->obj, bridge->num_proxies - i, &bridge->inlet);
+    bridge->proxies[i] = proxy_new(&bridge->obj, first + bridge->num_proxies - 1 - i, &bridge->inlet);
   }
 
   // create clock
@@ -180,6 +209,9 @@ static void bridge_dsp_perform(t_bridge *bridge, t_object *dsp64, double **ins,
 
 static void bridge_dsp(t_bridge *bridge, t_object *dsp64, short *count, double sampleRate, long maxVectorSize,
                        long flags) {
+  // update connections
+  maxgoDSP(bridge->ref, count);
+
   // add dsp handler
   object_method(dsp64, gensym("dsp_add64"), bridge, bridge_dsp_perform, 0, NULL);
 }
@@ -218,6 +250,13 @@ static void bridge_inletinfo(t_bridge *bridge, void *b, long i, char *v) {
   free(ret.r0);
 }
 
+static t_max_err bridge_notify(t_bridge *bridge, t_symbol *s, t_symbol *msg, void *sender, void *data) {
+  // handle notification
+  maxgoNotify(bridge->ref, s, msg, sender, data);
+
+  return MAX_ERR_NONE;
+}
+
 static void bridge_free(t_bridge *bridge) {
   // free object
   maxgoFree(bridge->ref);
@@ -251,6 +290,12 @@ void maxgo_init(char *name) {
   // init dsp
   class_dspinit(class);
 
+  // init obex
+  class_obexoffset_set(class, calcoffset(t_bridge, obex));
+
+  // add read-only latency attribute
+  CLASS_ATTR_LONG(class, "latency", ATTR_SET_OPAQUE_USER, t_bridge, latency);
+
   // add generic methods
   class_addmethod(class, (method)bridge_bang, "bang", 0);
   class_addmethod(class, (method)bridge_int, "int", A_LONG, 0);
@@ -262,6 +307,7 @@ void maxgo_init(char *name) {
   class_addmethod(class, (method)bridge_dblclick, "dblclick", 0);
   class_addmethod(class, (method)bridge_assist, "assist", A_CANT, 0);
   class_addmethod(class, (method)bridge_inletinfo, "inletinfo", A_CANT, 0);
+  class_addmethod(class, (method)bridge_notify, "notify", A_CANT, 0);
 
   // register class
   class_register(CLASS_BOX, class);
@@ -278,6 +324,159 @@ void maxgo_notify(void *ptr) {
   clock_delay(bridge->clock, 0);
 }
 
+void maxgo_set_latency(void *ptr, long latency) {
+  // get bridge
+  t_bridge *bridge = (t_bridge *)ptr;
+
+  // set latency
+  bridge->latency = latency;
+
+  // notify listeners
+  object_attr_touch((t_object *)bridge, gensym("latency"));
+}
+
+long maxgo_get_latency(void *ptr) {
+  // get latency
+  return (long)((t_bridge *)ptr)->latency;
+}
+
+/* Patchers */
+
+t_object *maxgo_lookup(void *ptr, t_symbol *key) {
+  // lookup object
+  t_object *obj = NULL;
+  object_obex_lookup(ptr, key, &obj);
+
+  return obj;
+}
+
+t_object *maxgo_newobject(t_object *patcher, char *text, double x, double y) {
+  // create object
+  t_object *box = newobject_sprintf(patcher, "@maxclass newobj @text \"%s\" @patching_position %.2f %.2f", text, x, y);
+
+  // free text
+  free(text);
+
+  return box;
+}
+
+t_max_err maxgo_connect(t_object *patcher, t_symbol *msg, t_object *src, long outlet, t_object *dst, long inlet) {
+  // prepare args
+  t_atom args[4];
+  atom_setobj(args, src);
+  atom_setlong(args + 1, outlet);
+  atom_setobj(args + 2, dst);
+  atom_setlong(args + 3, inlet);
+
+  // send message
+  t_atom ret;
+  return object_method_typed(patcher, msg, 4, args, &ret);
+}
+
+bool maxgo_valid(void *ptr) {
+  // check object
+  return !NOGOOD(ptr);
+}
+
+/* Files */
+
+static char *maxgo_abspath(short path, char *name) {
+  // get absolute path
+  char *out = malloc(MAX_PATH_CHARS);
+  if (path_toabsolutesystempath(path, name, out) != MAX_ERR_NONE) {
+    free(out);
+    return NULL;
+  }
+
+  return out;
+}
+
+char *maxgo_locate(char *name, t_fourcc *types, short num_types) {
+  // copy name
+  char buf[MAX_PATH_CHARS];
+  strncpy_zero(buf, name, MAX_PATH_CHARS);
+  free(name);
+
+  // locate file
+  short path = 0;
+  t_fourcc type = 0;
+  if (locatefile_extended(buf, &path, &type, num_types > 0 ? types : NULL, num_types) != 0) {
+    return NULL;
+  }
+
+  return maxgo_abspath(path, buf);
+}
+
+char *maxgo_conform(t_symbol *path) {
+  // conform path
+  char *out = malloc(MAX_PATH_CHARS);
+  if (path_nameconform(path->s_name, out, PATH_STYLE_NATIVE, PATH_TYPE_BOOT) != 0) {
+    free(out);
+    return NULL;
+  }
+
+  return out;
+}
+
+char *maxgo_open_dialog(char *prompt, t_fourcc *types, short num_types) {
+  // set prompt
+  if (prompt != NULL) {
+    open_promptset(prompt);
+    free(prompt);
+  }
+
+  // show dialog
+  char buf[MAX_PATH_CHARS] = {0};
+  short path = 0;
+  t_fourcc type = 0;
+  if (open_dialog(buf, &path, &type, num_types > 0 ? types : NULL, num_types) != 0) {
+    return NULL;
+  }
+
+  return maxgo_abspath(path, buf);
+}
+
+char *maxgo_save_dialog(char *prompt, char *name, t_fourcc *types, short num_types) {
+  // set prompt
+  if (prompt != NULL) {
+    saveas_promptset(prompt);
+    free(prompt);
+  }
+
+  // copy name
+  char buf[MAX_PATH_CHARS];
+  strncpy_zero(buf, name, MAX_PATH_CHARS);
+  free(name);
+
+  // show dialog
+  short path = 0;
+  t_fourcc type = 0;
+  if (saveasdialog_extended(buf, &path, &type, num_types > 0 ? types : NULL, num_types) != 0) {
+    return NULL;
+  }
+
+  return maxgo_abspath(path, buf);
+}
+
+/* Audio */
+
+bool maxgo_sndfile_info(char *name, t_sndfileinfo *info) {
+  // copy name
+  char buf[MAX_PATH_CHARS];
+  strncpy_zero(buf, name, MAX_PATH_CHARS);
+  free(name);
+
+  // locate file
+  short path = 0;
+  t_fourcc type = 0;
+  if (locatefile_extended(buf, &path, &type, NULL, 0) != 0) {
+    return false;
+  }
+
+  // get info
+  return sndfile_info(buf, path, type, info) == 0;
+}
+
 /* Threads */
 
 void maxgo_yield(void *p, void *ref) {
@@ -289,3 +488,13 @@ void maxgo_defer(unsigned long long ref) {
   // defer function call
   defer_low(NULL, (method)maxgo_yield, (void *)ref, 0, NULL);
 }
+
+void maxgo_defer_front(unsigned long long ref) {
+  // defer function call to front of queue
+  defer_front(NULL, (method)maxgo_yield, (void *)ref, 0, NULL);
+}
+
+void maxgo_schedule(unsigned long long ref, double delay) {
+  // schedule function call
+  schedule_fdelay(NULL, (method)maxgo_yield, delay, (void *)ref, 0, NULL);
+}
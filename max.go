@@ -1,15 +1,18 @@
 package max
 
-// #cgo CFLAGS: -I${SRCDIR}/lib/max -I${SRCDIR}/lib/msp
+// #cgo CFLAGS: -I${SRCDIR}/lib/max -I${SRCDIR}/lib/msp -Wno-multichar
 // #cgo windows CFLAGS: -DWIN_VERSION=1 -Wno-macro-redefined
 // #cgo darwin CFLAGS: -DMAC_VERSION=1
 // #cgo darwin LDFLAGS: -Wl,-undefined,dynamic_lookup
-// #cgo windows LDFLAGS: -L${SRCDIR}/lib/max/x64 -L${SRCDIR}/lib/msp/x64 -lMaxAPI -lMaxAudio
+// #cgo windows,amd64 LDFLAGS: -L${SRCDIR}/lib/max/x64 -L${SRCDIR}/lib/msp/x64 -lMaxAPI -lMaxAudio
+// #cgo windows,arm64 LDFLAGS: -L${SRCDIR}/lib/max/arm64 -L${SRCDIR}/lib/msp/arm64 -lMaxAPI -lMaxAudio
 // #include "max.h"
 import "C"
 
 import (
 	"fmt"
+	"runtime"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -52,9 +55,25 @@ func (t Type) enum() C.maxgo_type_e {
 	}
 }
 
-// Atom is a Max atom of type int64, float64 or string.
+// Atom is a Max atom of type int64, float64, string, *Ref, Separator, Dollar
+// or DollarSymbol.
 type Atom = interface{}
 
+// Separator is a comma or semicolon atom as produced by message boxes.
+type Separator byte
+
+// The available separators.
+const (
+	Comma     Separator = ','
+	Semicolon Separator = ';'
+)
+
+// Dollar is a dollar argument atom (e.g. $1) as produced by message boxes.
+type Dollar int64
+
+// DollarSymbol is a symbol atom containing a dollar argument (e.g. "$1-foo").
+type DollarSymbol string
+
 // Event describes an emitted event.
 type Event struct {
 	Outlet *Outlet
@@ -80,9 +99,20 @@ func Alert(format string, args ...interface{}) {
 	C.maxgo_alert(C.CString(fmt.Sprintf(format, args...))) // string freed by receiver
 }
 
-// Pretty will pretty print and log the provided values.
+// Pretty will pretty print and log the provided values. Atom lists are
+// formatted using FormatAtoms.
 func Pretty(a ...interface{}) {
-	Log(pretty.Sprint(a...))
+	// format atom lists
+	values := make([]interface{}, len(a))
+	for i, v := range a {
+		if atoms, ok := v.([]Atom); ok {
+			values[i] = "[" + FormatAtoms(atoms) + "]"
+		} else {
+			values[i] = v
+		}
+	}
+
+	Log(pretty.Sprint(values...))
 }
 
 var symbols sync.Map
@@ -115,6 +145,7 @@ type ProcessCallback func(obj *Object, ins, outs [][]float64)
 // FreeCallback is called to free objects.
 type FreeCallback func(obj *Object)
 
+var className string
 var initCallback InitCallback
 var handleCallback HandleCallback
 var processCallback ProcessCallback
@@ -170,7 +201,8 @@ func Init(name string, init InitCallback, handle HandleCallback, process Process
 		panic("already initialized")
 	}
 
-	// set callbacks
+	// set name and callbacks
+	className = name
 	initCallback = init
 	handleCallback = handle
 	processCallback = process
@@ -216,16 +248,18 @@ func maxgoInit(ptr unsafe.Pointer, argc int64, argv *C.t_atom) (uint64, int, int
 		return 0, 0, 0
 	}
 
-	// determine required proxies and signals
-	var proxies int
+	// determine required signal inlets, all inlets up to the last signal
+	// inlet are created as signal inlets that also accept messages
 	var signals int
-	for _, inlet := range obj.in {
+	for i, inlet := range obj.in {
 		if inlet.Type() == Signal {
-			signals++
-		} else {
-			proxies++
+			signals = i + 1
 		}
 	}
+	obj.signals = signals
+
+	// determine required proxies
+	proxies := len(obj.in) - signals
 	if signals == 0 && proxies > 0 {
 		proxies--
 	}
@@ -266,6 +300,9 @@ func maxgoHandle(ref uint64, msg *C.char, inlet int64, argc int64, argv *C.t_ato
 		return
 	}
 
+	// record activity
+	recordActivity()
+
 	// decode atoms
 	atoms := decodeAtoms(argc, argv)
 
@@ -274,6 +311,11 @@ func maxgoHandle(ref uint64, msg *C.char, inlet int64, argc int64, argv *C.t_ato
 
 	// check inlet
 	if inlet >= 0 {
+		// handle profiling messages
+		if obj.handleProfiling(name, atoms) {
+			return
+		}
+
 		// get inlet
 		in := obj.in[inlet]
 		if in == nil {
@@ -281,13 +323,13 @@ func maxgoHandle(ref uint64, msg *C.char, inlet int64, argc int64, argv *C.t_ato
 		}
 
 		// check signal
-		if in.typ == Signal {
+		if in.typ == Signal && !in.messages {
 			Error("message received on signal inlet %d", inlet)
 			return
 		}
 
 		// check name
-		if in.typ != Any && Type(name) != in.typ {
+		if in.typ != Any && in.typ != Signal && Type(name) != in.typ {
 			Error("invalid message received on inlet %d", inlet)
 			return
 		}
@@ -311,11 +353,23 @@ func maxgoHandle(ref uint64, msg *C.char, inlet int64, argc int64, argv *C.t_ato
 				return
 			}
 		}
+
+		// validate message
+		if m := obj.message(name); m != nil {
+			var err error
+			atoms, err = m.Validate(atoms)
+			if err != nil {
+				Error("invalid message %s received on inlet %d: %s (expected %s)", name, inlet, err, m)
+				return
+			}
+		}
 	}
 
 	// run callback if available
 	if handleCallback != nil {
-		handleCallback(obj, int(inlet), name, atoms)
+		measure(&obj.stats.handle, func() {
+			handleCallback(obj, int(inlet), name, atoms)
+		})
 	}
 }
 
@@ -329,14 +383,22 @@ func maxgoProcess(ref uint64, ins, outs **float64, numIns, numOuts uint8, sample
 		return
 	}
 
+	// lock thread if requested
+	if lockingProcess() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+	}
+
 	// prepare inputs and outputs
 	var inputs [][]float64
 	var outputs [][]float64
 
-	// convert inputs
+	// convert inputs of signal inlets
 	insSlice := unsafe.Slice(ins, int(numIns))
 	for i := uint8(0); i < numIns; i++ {
-		inputs = append(inputs, unsafe.Slice(insSlice[i], int(samples)))
+		if int(i) < len(obj.in) && obj.in[i].typ == Signal {
+			inputs = append(inputs, unsafe.Slice(insSlice[i], int(samples)))
+		}
 	}
 
 	// convert outputs
@@ -355,9 +417,26 @@ func maxgoProcess(ref uint64, ins, outs **float64, numIns, numOuts uint8, sample
 		tempOuts = append(tempOuts, make([]float64, samples))
 	}
 
+	// collect due events
+	obj.collectEvents(int(samples))
+
+	// get start if tracked
+	var start time.Time
+	tracked := trackingProcess()
+	if tracked {
+		start = time.Now()
+	}
+
 	// run callback if available
 	if processCallback != nil {
-		processCallback(obj, inputs, tempOuts)
+		measure(&obj.stats.process, func() {
+			processCallback(obj, inputs, tempOuts)
+		})
+	}
+
+	// record interval if tracked
+	if tracked {
+		recordProcess(start, time.Now())
 	}
 
 	// copy outputs reversed
@@ -368,6 +447,54 @@ func maxgoProcess(ref uint64, ins, outs **float64, numIns, numOuts uint8, sample
 	}
 }
 
+//export maxgoDSP
+func maxgoDSP(ref uint64, count *C.short) {
+	// get object
+	objectsMutex.Lock()
+	obj, ok := objects[ref]
+	objectsMutex.Unlock()
+	if !ok {
+		return
+	}
+
+	// count signals
+	signals := obj.signals
+	for _, outlet := range obj.out {
+		if outlet.typ == Signal {
+			signals++
+		}
+	}
+
+	// check count
+	if count == nil || signals == 0 {
+		return
+	}
+
+	// cast to slice
+	list := unsafe.Slice(count, signals)
+
+	// update inlets
+	for i := 0; i < obj.signals; i++ {
+		atomic.StoreUint32(&obj.in[i].connected, boolToUint32(list[i] > 0))
+	}
+
+	// update outlets
+	i := obj.signals
+	for _, outlet := range obj.out {
+		if outlet.typ == Signal {
+			atomic.StoreUint32(&outlet.connected, boolToUint32(list[i] > 0))
+			i++
+		}
+	}
+}
+
+func boolToUint32(b bool) uint32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
 //export maxgoPop
 func maxgoPop(ref uint64) (unsafe.Pointer, C.maxgo_type_e, *C.t_symbol, int64, *C.t_atom, bool) {
 	// get object
@@ -415,6 +542,9 @@ func maxgoDescribe(ref uint64, io, i int64) (*C.char, bool) {
 	if io == 1 {
 		if int(i) < len(obj.in) {
 			label := fmt.Sprintf("%s (%s)", obj.in[i].label, obj.in[i].typ)
+			if list := obj.signatures(obj.in[i].typ); len(list) > 0 {
+				label += ": " + strings.Join(list, ", ")
+			}
 			return C.CString(label), obj.in[i].hot // string freed by receiver
 		}
 	} else {
@@ -438,6 +568,12 @@ func maxgoFree(ref uint64) {
 		return
 	}
 
+	// drop pending calls and await running calls
+	dropCalls(obj)
+
+	// detach and unregister
+	obj.release()
+
 	// run callback if available
 	if freeCallback != nil {
 		freeCallback(obj)
@@ -448,11 +584,26 @@ func maxgoFree(ref uint64) {
 
 // Object is single Max object.
 type Object struct {
-	ref   uint64
-	ptr   unsafe.Pointer
-	in    []*Inlet
-	out   []*Outlet
-	queue chan Event
+	ref        uint64
+	ptr        unsafe.Pointer
+	in         []*Inlet
+	out        []*Outlet
+	signals    int
+	messages   []*Message
+	queue      chan Event
+	attached   map[*C.t_object]NotifyHandler
+	tracked    map[unsafe.Pointer]*lifetime
+	buffers    map[string]*bufferRef
+	registered bool
+	released   bool
+	freed      bool
+	running    sync.WaitGroup
+	mutex      sync.Mutex
+	stats      objectStats
+
+	events      *eventQueue
+	eventsOnce  sync.Once
+	eventsReady uint32
 }
 
 // Push will add the provided events to the objects queue.
@@ -470,29 +621,25 @@ func (o *Object) Push(events ...Event) {
 	C.maxgo_notify(o.ptr)
 }
 
+func (o *Object) post(format string, args ...interface{}) {
+	C.maxgo_object_post((*C.t_object)(o.ptr), C.CString(fmt.Sprintf(format, args...))) // string freed by receiver
+}
+
 // Inlet is a single Max inlet.
 type Inlet struct {
-	typ   Type
-	label string
-	hot   bool
+	typ       Type
+	label     string
+	hot       bool
+	messages  bool
+	connected uint32
 }
 
 // Inlet will declare an inlet. If no inlets are added to an object it will have
-// a default inlet to receive messages.
+// a default inlet to receive messages. Signal and other inlets may be declared
+// in any order. Inlets left of the last signal inlet are created as signal
+// inlets that only receive messages, their inputs are not passed to the
+// process callback.
 func (o *Object) Inlet(typ Type, label string, hot bool) *Inlet {
-	// check signal
-	if typ == Signal {
-		var nonSignals int
-		for _, in := range o.in {
-			if in.typ != Signal {
-				nonSignals++
-			}
-		}
-		if nonSignals > 0 {
-			panic("signal only supported as first inlets")
-		}
-	}
-
 	// create inlet
 	inlet := &Inlet{typ: typ, label: label, hot: hot}
 
@@ -512,29 +659,32 @@ func (i *Inlet) Label() string {
 	return i.label
 }
 
+// AcceptMessages will allow a signal inlet to receive messages (e.g. a float
+// to set a constant value) which are then delivered to the handler like
+// messages on other inlets. Messages on signal inlets are rejected otherwise.
+func (i *Inlet) AcceptMessages() *Inlet {
+	i.messages = true
+	return i
+}
+
+// Connected will return whether a signal is connected to the signal inlet. The
+// status is updated when the DSP chain is compiled.
+func (i *Inlet) Connected() bool {
+	return atomic.LoadUint32(&i.connected) == 1
+}
+
 // Outlet is a single MAx outlet.
 type Outlet struct {
-	obj   *Object
-	typ   Type
-	label string
-	ptr   unsafe.Pointer
+	obj       *Object
+	typ       Type
+	label     string
+	ptr       unsafe.Pointer
+	connected uint32
 }
 
-// Outlet will declare an outlet.
+// Outlet will declare an outlet. Signal and other outlets may be declared in
+// any order.
 func (o *Object) Outlet(typ Type, label string) *Outlet {
-	// check signal
-	if typ == Signal {
-		var nonSignals int
-		for _, out := range o.out {
-			if out.typ != Signal {
-				nonSignals++
-			}
-		}
-		if nonSignals > 0 {
-			panic("signal only supported as first outlets")
-		}
-	}
-
 	// create outlet
 	outlet := &Outlet{obj: o, typ: typ, label: label}
 
@@ -554,6 +704,12 @@ func (o *Outlet) Label() string {
 	return o.label
 }
 
+// Connected will return whether the signal outlet is connected to a signal
+// inlet. The status is updated when the DSP chain is compiled.
+func (o *Outlet) Connected() bool {
+	return atomic.LoadUint32(&o.connected) == 1
+}
+
 // Bang will send a bang.
 func (o *Outlet) Bang() {
 	if o.typ == Bang || o.typ == Any {
@@ -599,44 +755,42 @@ func (o *Outlet) Any(msg string, atoms []Atom) {
 	}
 }
 
-/* Threads */
-
-var queue = map[uint64]func(){}
-var queueMutex sync.Mutex
-
-// IsMainThread will return if the Max main thead is executing.
-func IsMainThread() bool {
-	return C.systhread_ismainthread() == 1
-}
+/* Atoms */
 
-//export maxgoYield
-func maxgoYield(ref uint64) {
-	// get function
-	queueMutex.Lock()
-	fn := queue[ref]
-	delete(queue, ref)
-	queueMutex.Unlock()
+// Segment is a part of an atom list delimited by a separator.
+type Segment struct {
+	// The atoms of the segment without the separator.
+	Atoms []Atom
 
-	// execute function
-	fn()
+	// The separator that terminated the segment or zero for the last segment.
+	Separator Separator
 }
 
-// Defer will run the provided function on the Max main thread.
-func Defer(fn func()) {
-	// get reference
-	ref := atomic.AddUint64(&counter, 1)
+// Split will split the atoms at commas and semicolons into segments. Empty
+// segments are omitted.
+func Split(atoms []Atom) []Segment {
+	// split atoms
+	var list []Segment
+	var start int
+	for i, atom := range atoms {
+		sep, ok := atom.(Separator)
+		if !ok {
+			continue
+		}
+		if i > start {
+			list = append(list, Segment{Atoms: atoms[start:i], Separator: sep})
+		}
+		start = i + 1
+	}
 
-	// store function
-	queueMutex.Lock()
-	queue[ref] = fn
-	queueMutex.Unlock()
+	// add last segment
+	if start < len(atoms) {
+		list = append(list, Segment{Atoms: atoms[start:]})
+	}
 
-	// defer call
-	C.maxgo_defer(C.ulonglong(ref))
+	return list
 }
 
-/* Atoms */
-
 func decodeAtoms(argc int64, argv *C.t_atom) []Atom {
 	// check empty
 	if argc == 0 {
@@ -658,6 +812,16 @@ func decodeAtoms(argc int64, argv *C.t_atom) []Atom {
 			atoms[i] = float64(C.atom_getfloat(&item))
 		case C.A_SYM:
 			atoms[i] = C.GoString(C.atom_getsym(&item).s_name)
+		case C.A_OBJ:
+			atoms[i] = &Ref{ptr: (*C.t_object)(C.atom_getobj(&item))}
+		case C.A_COMMA:
+			atoms[i] = Comma
+		case C.A_SEMI:
+			atoms[i] = Semicolon
+		case C.A_DOLLAR:
+			atoms[i] = Dollar(C.maxgo_atom_getlong(&item))
+		case C.A_DOLLSYM:
+			atoms[i] = DollarSymbol(C.GoString(C.maxgo_atom_getsym(&item).s_name))
 		default:
 			atoms[i] = nil
 		}
@@ -688,6 +852,19 @@ func encodeAtoms(atoms []Atom) (int64, *C.t_atom) {
 			C.atom_setfloat(&slice[i], C.double(atom))
 		case string:
 			C.atom_setsym(&slice[i], gensym(atom))
+		case *Ref:
+			// nil references are encoded as null objects
+			if atom == nil {
+				C.atom_setobj(&slice[i], nil)
+			} else {
+				C.atom_setobj(&slice[i], atom.object())
+			}
+		case Separator:
+			C.maxgo_atom_setsep(&slice[i], atom == Semicolon)
+		case Dollar:
+			C.maxgo_atom_setdollar(&slice[i], C.t_atom_long(atom))
+		case DollarSymbol:
+			C.maxgo_atom_setdollsym(&slice[i], gensym(string(atom)))
 		}
 	}
 
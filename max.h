@@ -6,6 +6,8 @@
 #endif
 
 #include <ext.h>
+#include <ext_buffer.h>
+#include <ext_sndfile.h>
 #include <z_dsp.h>
 
 typedef enum {
@@ -19,10 +21,29 @@ typedef enum {
 
 void maxgo_log(char *str);
 void maxgo_error(char *str);
+void maxgo_object_post(t_object *obj, char *str);
 void maxgo_alert(char *str);
 t_symbol *maxgo_gensym(char *name);
+t_atom_long maxgo_atom_getlong(t_atom *atom);
+t_symbol *maxgo_atom_getsym(t_atom *atom);
+void maxgo_atom_setdollar(t_atom *atom, t_atom_long n);
+void maxgo_atom_setdollsym(t_atom *atom, t_symbol *sym);
+void maxgo_atom_setsep(t_atom *atom, bool semi);
 void maxgo_init(char *name);
 void maxgo_notify(void *ptr);
+void maxgo_set_latency(void *ptr, long latency);
+long maxgo_get_latency(void *ptr);
+t_object *maxgo_lookup(void *ptr, t_symbol *key);
+t_object *maxgo_newobject(t_object *patcher, char *text, double x, double y);
+t_max_err maxgo_connect(t_object *patcher, t_symbol *msg, t_object *src, long outlet, t_object *dst, long inlet);
+bool maxgo_valid(void *ptr);
+char *maxgo_locate(char *name, t_fourcc *types, short num_types);
+char *maxgo_conform(t_symbol *path);
+char *maxgo_open_dialog(char *prompt, t_fourcc *types, short num_types);
+char *maxgo_save_dialog(char *prompt, char *name, t_fourcc *types, short num_types);
+bool maxgo_sndfile_info(char *name, t_sndfileinfo *info);
 void maxgo_defer(unsigned long long ref);
+void maxgo_defer_front(unsigned long long ref);
+void maxgo_schedule(unsigned long long ref, double delay);
 
 #endif
@@ -0,0 +1,10 @@
+//go:build go1.19
+
+package max
+
+import "runtime/debug"
+
+func setMemoryLimit(limit int64) bool {
+	debug.SetMemoryLimit(limit)
+	return true
+}
@@ -0,0 +1,7 @@
+//go:build !go1.19
+
+package max
+
+func setMemoryLimit(int64) bool {
+	return false
+}
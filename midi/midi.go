@@ -0,0 +1,168 @@
+// Package midi provides typed MIDI events, a parser for MIDI byte streams and
+// an outlet that emits events as byte streams compatible with "midiout".
+package midi
+
+// Event is a typed MIDI event.
+type Event interface {
+	// Bytes returns the serialized event.
+	Bytes() []byte
+}
+
+// NoteOff is a note off event. Channels are numbered from 0 to 15.
+type NoteOff struct {
+	Channel  uint8
+	Key      uint8
+	Velocity uint8
+}
+
+// Bytes implements the Event interface.
+func (e NoteOff) Bytes() []byte {
+	return []byte{0x80 | e.Channel&0xF, e.Key & 0x7F, e.Velocity & 0x7F}
+}
+
+// NoteOn is a note on event. Note on events with a velocity of zero are
+// parsed as note off events.
+type NoteOn struct {
+	Channel  uint8
+	Key      uint8
+	Velocity uint8
+}
+
+// Bytes implements the Event interface.
+func (e NoteOn) Bytes() []byte {
+	return []byte{0x90 | e.Channel&0xF, e.Key & 0x7F, e.Velocity & 0x7F}
+}
+
+// PolyAftertouch is a polyphonic key pressure event.
+type PolyAftertouch struct {
+	Channel  uint8
+	Key      uint8
+	Pressure uint8
+}
+
+// Bytes implements the Event interface.
+func (e PolyAftertouch) Bytes() []byte {
+	return []byte{0xA0 | e.Channel&0xF, e.Key & 0x7F, e.Pressure & 0x7F}
+}
+
+// ControlChange is a control change event.
+type ControlChange struct {
+	Channel    uint8
+	Controller uint8
+	Value      uint8
+}
+
+// Bytes implements the Event interface.
+func (e ControlChange) Bytes() []byte {
+	return []byte{0xB0 | e.Channel&0xF, e.Controller & 0x7F, e.Value & 0x7F}
+}
+
+// ProgramChange is a program change event.
+type ProgramChange struct {
+	Channel uint8
+	Program uint8
+}
+
+// Bytes implements the Event interface.
+func (e ProgramChange) Bytes() []byte {
+	return []byte{0xC0 | e.Channel&0xF, e.Program & 0x7F}
+}
+
+// ChannelAftertouch is a channel pressure event.
+type ChannelAftertouch struct {
+	Channel  uint8
+	Pressure uint8
+}
+
+// Bytes implements the Event interface.
+func (e ChannelAftertouch) Bytes() []byte {
+	return []byte{0xD0 | e.Channel&0xF, e.Pressure & 0x7F}
+}
+
+// PitchBend is a pitch bend event with a value from -8192 to 8191.
+type PitchBend struct {
+	Channel uint8
+	Value   int16
+}
+
+// Bytes implements the Event interface.
+func (e PitchBend) Bytes() []byte {
+	// clamp value
+	value := int(e.Value)
+	if value < -8192 {
+		value = -8192
+	} else if value > 8191 {
+		value = 8191
+	}
+	value += 8192
+
+	return []byte{0xE0 | e.Channel&0xF, byte(value & 0x7F), byte(value >> 7 & 0x7F)}
+}
+
+// SysEx is a system exclusive message. The data excludes the start (0xF0) and
+// end (0xF7) bytes.
+type SysEx struct {
+	Data []byte
+}
+
+// Bytes implements the Event interface.
+func (e SysEx) Bytes() []byte {
+	// prepare message
+	buf := make([]byte, 0, len(e.Data)+2)
+	buf = append(buf, 0xF0)
+	for _, b := range e.Data {
+		buf = append(buf, b&0x7F)
+	}
+	buf = append(buf, 0xF7)
+
+	return buf
+}
+
+// QuarterFrame is a MIDI time code quarter frame message.
+type QuarterFrame struct {
+	Value uint8
+}
+
+// Bytes implements the Event interface.
+func (e QuarterFrame) Bytes() []byte {
+	return []byte{0xF1, e.Value & 0x7F}
+}
+
+// SongPosition is a song position pointer in MIDI beats (sixteenth notes).
+type SongPosition struct {
+	Beats uint16
+}
+
+// Bytes implements the Event interface.
+func (e SongPosition) Bytes() []byte {
+	return []byte{0xF2, byte(e.Beats & 0x7F), byte(e.Beats >> 7 & 0x7F)}
+}
+
+// SongSelect is a song select message.
+type SongSelect struct {
+	Song uint8
+}
+
+// Bytes implements the Event interface.
+func (e SongSelect) Bytes() []byte {
+	return []byte{0xF3, e.Song & 0x7F}
+}
+
+// Message is a single byte system message.
+type Message byte
+
+// The available single byte system messages.
+const (
+	TuneRequest   Message = 0xF6
+	Clock         Message = 0xF8
+	Start         Message = 0xFA
+	Continue      Message = 0xFB
+	Stop          Message = 0xFC
+	ActiveSensing Message = 0xFE
+	Reset         Message = 0xFF
+)
+
+// Bytes implements the Event interface.
+func (m Message) Bytes() []byte {
+	return []byte{byte(m)}
+}
@@ -0,0 +1,35 @@
+package midi
+
+// ListSender is implemented by *max.Outlet.
+type ListSender interface {
+	List(atoms []interface{})
+}
+
+// Outlet serializes typed events into the byte lists expected by "midiout".
+type Outlet struct {
+	out ListSender
+}
+
+// NewOutlet will create a MIDI outlet that emits events using the provided
+// outlet, which must be of type max.List or max.Any.
+func NewOutlet(out ListSender) *Outlet {
+	return &Outlet{out: out}
+}
+
+// Send will emit the provided events in order. Every event, including system
+// exclusive messages, is emitted as a single list of bytes and therefore takes
+// one slot in the objects outgoing event queue, which holds 256 events. Events
+// that do not fit into the queue are dropped after a timeout.
+func (o *Outlet) Send(events ...Event) {
+	for _, evt := range events {
+		// convert bytes
+		data := evt.Bytes()
+		atoms := make([]interface{}, len(data))
+		for i, b := range data {
+			atoms[i] = int64(b)
+		}
+
+		// emit list
+		o.out.List(atoms)
+	}
+}
@@ -0,0 +1,175 @@
+package midi
+
+// Parser turns a stream of MIDI bytes as received from "midiin" into typed
+// events. It supports running status, system exclusive messages and real-time
+// messages interleaved with other messages.
+type Parser struct {
+	status  byte
+	data    []byte
+	sysex   []byte
+	inSysex bool
+}
+
+// Feed will add a byte to the parser and return the completed event, if any.
+func (p *Parser) Feed(b byte) Event {
+	// handle real-time messages, which may appear anywhere
+	if b >= 0xF8 {
+		switch Message(b) {
+		case Clock, Start, Continue, Stop, ActiveSensing, Reset:
+			return Message(b)
+		default:
+			return nil
+		}
+	}
+
+	// handle status bytes
+	if b&0x80 != 0 {
+		// finish system exclusive message, a stray end byte is a system
+		// common message and clears running status
+		if b == 0xF7 {
+			if !p.inSysex {
+				p.status = 0
+				p.data = p.data[:0]
+				return nil
+			}
+			p.inSysex = false
+			evt := SysEx{Data: p.sysex}
+			p.sysex = nil
+			return evt
+		}
+
+		// any other status byte aborts system exclusive messages
+		p.inSysex = false
+		p.sysex = nil
+		p.data = p.data[:0]
+
+		// handle system messages
+		switch b {
+		case 0xF0:
+			p.status = 0
+			p.inSysex = true
+			p.sysex = []byte{}
+			return nil
+		case 0xF6:
+			p.status = 0
+			return TuneRequest
+		}
+
+		// set status, system common messages clear running status
+		p.status = b
+
+		return nil
+	}
+
+	// collect system exclusive data
+	if p.inSysex {
+		p.sysex = append(p.sysex, b)
+		return nil
+	}
+
+	// ignore data without status
+	if p.status == 0 {
+		return nil
+	}
+
+	// collect data
+	p.data = append(p.data, b)
+	if len(p.data) < dataLength(p.status) {
+		return nil
+	}
+
+	// build event
+	evt := p.event()
+	p.data = p.data[:0]
+
+	// clear status for system common messages
+	if p.status >= 0xF0 {
+		p.status = 0
+	}
+
+	return evt
+}
+
+// Parse will feed all provided bytes and return the completed events.
+func (p *Parser) Parse(bytes ...byte) []Event {
+	var list []Event
+	for _, b := range bytes {
+		if evt := p.Feed(b); evt != nil {
+			list = append(list, evt)
+		}
+	}
+
+	return list
+}
+
+// ParseInts will feed all provided integers, as received from "midiin", and
+// return the completed events. Values outside the byte range are ignored.
+func (p *Parser) ParseInts(ints ...int64) []Event {
+	var list []Event
+	for _, n := range ints {
+		if n < 0 || n > 0xFF {
+			continue
+		}
+		if evt := p.Feed(byte(n)); evt != nil {
+			list = append(list, evt)
+		}
+	}
+
+	return list
+}
+
+func (p *Parser) event() Event {
+	// get channel
+	ch := p.status & 0xF
+
+	// build event
+	switch p.status & 0xF0 {
+	case 0x80:
+		return NoteOff{Channel: ch, Key: p.data[0], Velocity: p.data[1]}
+	case 0x90:
+		if p.data[1] == 0 {
+			return NoteOff{Channel: ch, Key: p.data[0]}
+		}
+		return NoteOn{Channel: ch, Key: p.data[0], Velocity: p.data[1]}
+	case 0xA0:
+		return PolyAftertouch{Channel: ch, Key: p.data[0], Pressure: p.data[1]}
+	case 0xB0:
+		return ControlChange{Channel: ch, Controller: p.data[0], Value: p.data[1]}
+	case 0xC0:
+		return ProgramChange{Channel: ch, Program: p.data[0]}
+	case 0xD0:
+		return ChannelAftertouch{Channel: ch, Pressure: p.data[0]}
+	case 0xE0:
+		return PitchBend{Channel: ch, Value: int16(int(p.data[0])|int(p.data[1])<<7) - 8192}
+	}
+
+	// build system common event
+	switch p.status {
+	case 0xF1:
+		return QuarterFrame{Value: p.data[0]}
+	case 0xF2:
+		return SongPosition{Beats: uint16(p.data[0]) | uint16(p.data[1])<<7}
+	case 0xF3:
+		return SongSelect{Song: p.data[0]}
+	}
+
+	return nil
+}
+
+func dataLength(status byte) int {
+	switch status & 0xF0 {
+	case 0xC0, 0xD0:
+		return 1
+	case 0xF0:
+		switch status {
+		case 0xF1, 0xF3:
+			return 1
+		case 0xF2:
+			return 2
+		default:
+			return 0
+		}
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,233 @@
+package midi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for _, item := range []struct {
+		name   string
+		bytes  []byte
+		events []Event
+	}{
+		{
+			name:   "NoteOn",
+			bytes:  []byte{0x91, 60, 100},
+			events: []Event{NoteOn{Channel: 1, Key: 60, Velocity: 100}},
+		},
+		{
+			name:   "NoteOnZero",
+			bytes:  []byte{0x91, 60, 0},
+			events: []Event{NoteOff{Channel: 1, Key: 60}},
+		},
+		{
+			name:  "RunningStatus",
+			bytes: []byte{0x90, 60, 100, 62, 101, 64, 0},
+			events: []Event{
+				NoteOn{Key: 60, Velocity: 100},
+				NoteOn{Key: 62, Velocity: 101},
+				NoteOff{Key: 64},
+			},
+		},
+		{
+			name:  "RunningStatusSingle",
+			bytes: []byte{0xC3, 1, 2, 3},
+			events: []Event{
+				ProgramChange{Channel: 3, Program: 1},
+				ProgramChange{Channel: 3, Program: 2},
+				ProgramChange{Channel: 3, Program: 3},
+			},
+		},
+		{
+			name:   "DataWithoutStatus",
+			bytes:  []byte{60, 100, 0xB0, 7, 127},
+			events: []Event{ControlChange{Controller: 7, Value: 127}},
+		},
+		{
+			name:   "SysEx",
+			bytes:  []byte{0xF0, 0x7E, 0x00, 0x06, 0x01, 0xF7},
+			events: []Event{SysEx{Data: []byte{0x7E, 0x00, 0x06, 0x01}}},
+		},
+		{
+			name:   "SysExEmpty",
+			bytes:  []byte{0xF0, 0xF7},
+			events: []Event{SysEx{Data: []byte{}}},
+		},
+		{
+			name:  "SysExRealTime",
+			bytes: []byte{0xF0, 0x01, 0xF8, 0x02, 0xFE, 0xF7},
+			events: []Event{
+				Clock,
+				ActiveSensing,
+				SysEx{Data: []byte{0x01, 0x02}},
+			},
+		},
+		{
+			name:   "SysExAborted",
+			bytes:  []byte{0xF0, 0x01, 0x02, 0x90, 60, 100, 0xF7},
+			events: []Event{NoteOn{Key: 60, Velocity: 100}},
+		},
+		{
+			name:  "RealTimeMidMessage",
+			bytes: []byte{0x90, 0xF8, 60, 0xFA, 100, 0xFC, 62, 0xFB, 101},
+			events: []Event{
+				Clock,
+				Start,
+				NoteOn{Key: 60, Velocity: 100},
+				Stop,
+				Continue,
+				NoteOn{Key: 62, Velocity: 101},
+			},
+		},
+		{
+			name:   "RealTimeUndefined",
+			bytes:  []byte{0x90, 60, 0xF9, 0xFD, 100},
+			events: []Event{NoteOn{Key: 60, Velocity: 100}},
+		},
+		{
+			name:  "RealTimeKeepsRunningStatus",
+			bytes: []byte{0x90, 60, 100, 0xFF, 62, 101},
+			events: []Event{
+				NoteOn{Key: 60, Velocity: 100},
+				Reset,
+				NoteOn{Key: 62, Velocity: 101},
+			},
+		},
+		{
+			name:   "SystemCommonClearsRunningStatus",
+			bytes:  []byte{0x90, 60, 100, 0xF3, 5, 62, 101},
+			events: []Event{NoteOn{Key: 60, Velocity: 100}, SongSelect{Song: 5}},
+		},
+		{
+			name:   "TuneRequestClearsRunningStatus",
+			bytes:  []byte{0x90, 60, 100, 0xF6, 62, 101},
+			events: []Event{NoteOn{Key: 60, Velocity: 100}, TuneRequest},
+		},
+		{
+			name:   "StrayEndClearsRunningStatus",
+			bytes:  []byte{0x90, 60, 100, 0xF7, 62, 101},
+			events: []Event{NoteOn{Key: 60, Velocity: 100}},
+		},
+		{
+			name:   "StrayEndMidMessage",
+			bytes:  []byte{0x90, 60, 0xF7, 100, 0x80, 60, 0},
+			events: []Event{NoteOff{Key: 60}},
+		},
+		{
+			name:  "PitchBend",
+			bytes: []byte{0xE2, 0x00, 0x00, 0x00, 0x40, 0x7F, 0x7F, 0x01, 0x00},
+			events: []Event{
+				PitchBend{Channel: 2, Value: -8192},
+				PitchBend{Channel: 2, Value: 0},
+				PitchBend{Channel: 2, Value: 8191},
+				PitchBend{Channel: 2, Value: -8191},
+			},
+		},
+		{
+			name:  "SongPosition",
+			bytes: []byte{0xF2, 0x00, 0x00, 0xF2, 0x7F, 0x7F, 0xF2, 0x01, 0x02},
+			events: []Event{
+				SongPosition{Beats: 0},
+				SongPosition{Beats: 16383},
+				SongPosition{Beats: 257},
+			},
+		},
+	} {
+		var p Parser
+		events := p.Parse(item.bytes...)
+		if !reflect.DeepEqual(events, item.events) {
+			t.Errorf("%s: expected %v, got %v", item.name, item.events, events)
+		}
+	}
+}
+
+func TestParseInts(t *testing.T) {
+	var p Parser
+	events := p.ParseInts(0x90, -1, 60, 256, 100)
+	expected := []Event{NoteOn{Key: 60, Velocity: 100}}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("expected %v, got %v", expected, events)
+	}
+}
+
+func TestBytes(t *testing.T) {
+	for _, item := range []struct {
+		event Event
+		bytes []byte
+	}{
+		{NoteOff{Channel: 15, Key: 60, Velocity: 64}, []byte{0x8F, 60, 64}},
+		{NoteOn{Channel: 0, Key: 127, Velocity: 1}, []byte{0x90, 127, 1}},
+		{PolyAftertouch{Channel: 1, Key: 2, Pressure: 3}, []byte{0xA1, 2, 3}},
+		{ControlChange{Channel: 2, Controller: 7, Value: 100}, []byte{0xB2, 7, 100}},
+		{ProgramChange{Channel: 3, Program: 42}, []byte{0xC3, 42}},
+		{ChannelAftertouch{Channel: 4, Pressure: 5}, []byte{0xD4, 5}},
+		{PitchBend{Value: -8192}, []byte{0xE0, 0x00, 0x00}},
+		{PitchBend{Value: 0}, []byte{0xE0, 0x00, 0x40}},
+		{PitchBend{Value: 8191}, []byte{0xE0, 0x7F, 0x7F}},
+		{PitchBend{Value: 1000}, []byte{0xE0, 0x68, 0x47}},
+		{PitchBend{Value: -9000}, []byte{0xE0, 0x00, 0x00}},
+		{PitchBend{Value: 9000}, []byte{0xE0, 0x7F, 0x7F}},
+		{SysEx{Data: []byte{1, 2, 0xFF}}, []byte{0xF0, 1, 2, 0x7F, 0xF7}},
+		{QuarterFrame{Value: 0x35}, []byte{0xF1, 0x35}},
+		{SongPosition{Beats: 0}, []byte{0xF2, 0x00, 0x00}},
+		{SongPosition{Beats: 16383}, []byte{0xF2, 0x7F, 0x7F}},
+		{SongPosition{Beats: 1000}, []byte{0xF2, 0x68, 0x07}},
+		{SongSelect{Song: 9}, []byte{0xF3, 9}},
+		{TuneRequest, []byte{0xF6}},
+		{Clock, []byte{0xF8}},
+	} {
+		bytes := item.event.Bytes()
+		if !reflect.DeepEqual(bytes, item.bytes) {
+			t.Errorf("%v: expected % X, got % X", item.event, item.bytes, bytes)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	events := []Event{
+		NoteOff{Channel: 15, Key: 60, Velocity: 64},
+		NoteOn{Channel: 0, Key: 127, Velocity: 1},
+		PolyAftertouch{Channel: 1, Key: 2, Pressure: 3},
+		ControlChange{Channel: 2, Controller: 7, Value: 100},
+		ProgramChange{Channel: 3, Program: 42},
+		ChannelAftertouch{Channel: 4, Pressure: 5},
+		PitchBend{Channel: 5, Value: -8192},
+		PitchBend{Channel: 5, Value: -1},
+		PitchBend{Channel: 5, Value: 0},
+		PitchBend{Channel: 5, Value: 1234},
+		PitchBend{Channel: 5, Value: 8191},
+		SysEx{Data: []byte{0x7E, 0x7F, 0x09, 0x01}},
+		QuarterFrame{Value: 0x35},
+		SongPosition{Beats: 0},
+		SongPosition{Beats: 1000},
+		SongPosition{Beats: 16383},
+		SongSelect{Song: 9},
+		TuneRequest,
+		Clock,
+		Start,
+		Continue,
+		Stop,
+		ActiveSensing,
+		Reset,
+	}
+
+	// parse events individually
+	var p Parser
+	for _, evt := range events {
+		parsed := p.Parse(evt.Bytes()...)
+		if !reflect.DeepEqual(parsed, []Event{evt}) {
+			t.Errorf("%v: got %v", evt, parsed)
+		}
+	}
+
+	// parse events as a single stream
+	var stream []byte
+	for _, evt := range events {
+		stream = append(stream, evt.Bytes()...)
+	}
+	parsed := p.Parse(stream...)
+	if !reflect.DeepEqual(parsed, events) {
+		t.Errorf("expected %v, got %v", events, parsed)
+	}
+}
@@ -0,0 +1,261 @@
+package max
+
+// #include "max.h"
+import "C"
+
+import (
+	"errors"
+	"sync/atomic"
+	"unsafe"
+)
+
+// NotifyHandler is called with notifications sent by an attached object. The
+// sender references the notifying object. The data is specific to the message
+// and may not reference a valid object.
+type NotifyHandler func(msg string, sender, data *Ref)
+
+// Attach will attach the object to the object registered with the provided
+// name in the specified namespace (e.g. "buffer~") and call the handler with
+// all notifications sent by that object. It must be called on the Max main
+// thread.
+func (o *Object) Attach(namespace, name string, handler NotifyHandler) (*Ref, error) {
+	// attach object
+	ptr := C.object_attach(gensym(namespace), gensym(name), o.ptr)
+	if ptr == nil {
+		return nil, errors.New("object not found")
+	}
+
+	// store handler
+	o.mutex.Lock()
+	if o.attached == nil {
+		o.attached = map[*C.t_object]NotifyHandler{}
+	}
+	o.attached[(*C.t_object)(ptr)] = handler
+	life := o.tracked[unsafe.Pointer(ptr)]
+	if life == nil {
+		life = &lifetime{alive: 1}
+		if o.tracked == nil {
+			o.tracked = map[unsafe.Pointer]*lifetime{}
+		}
+		o.tracked[unsafe.Pointer(ptr)] = life
+	}
+	o.mutex.Unlock()
+
+	return &Ref{ptr: (*C.t_object)(ptr), life: life}, nil
+}
+
+// Detach will detach the object from the object registered with the provided
+// name in the specified namespace. References returned by Attach become
+// invalid. It must be called on the Max main thread.
+func (o *Object) Detach(namespace, name string) {
+	// find registered object
+	ptr := C.object_findregistered(gensym(namespace), gensym(name))
+
+	// remove handler and lifetime
+	o.mutex.Lock()
+	delete(o.attached, (*C.t_object)(ptr))
+	life := o.tracked[ptr]
+	delete(o.tracked, ptr)
+	o.mutex.Unlock()
+	if life != nil {
+		life.invalidate()
+	}
+
+	// detach object
+	C.object_detach(gensym(namespace), gensym(name), o.ptr)
+}
+
+// Register will register the object with the provided name in the specified
+// namespace so that other objects may attach to it. It must be called on the
+// Max main thread.
+func (o *Object) Register(namespace, name string) error {
+	// register object
+	ptr := C.object_register(gensym(namespace), gensym(name), o.ptr)
+	if ptr != o.ptr {
+		return errors.New("name already registered")
+	}
+
+	// set flag
+	o.mutex.Lock()
+	o.registered = true
+	o.mutex.Unlock()
+
+	return nil
+}
+
+// Notify will send a notification with the provided message to all attached
+// objects. The object must have been registered before.
+func (o *Object) Notify(msg string) {
+	C.object_notify(o.ptr, gensym(msg), nil)
+}
+
+//export maxgoNotify
+func maxgoNotify(ref uint64, namespace, msg *C.t_symbol, sender, data unsafe.Pointer) {
+	// get object
+	objectsMutex.Lock()
+	obj, ok := objects[ref]
+	objectsMutex.Unlock()
+	if !ok {
+		return
+	}
+
+	// get name
+	name := C.GoString(msg.s_name)
+
+	// get handler, buffers and lifetime of freed object
+	obj.mutex.Lock()
+	handler := obj.attached[(*C.t_object)(sender)]
+	buffers := make([]*bufferRef, 0, len(obj.buffers))
+	for _, buf := range obj.buffers {
+		buffers = append(buffers, buf)
+	}
+	life := obj.tracked[sender]
+	if name == "free" {
+		delete(obj.tracked, sender)
+		delete(obj.attached, (*C.t_object)(sender))
+	}
+	obj.mutex.Unlock()
+
+	// forward to buffer references
+	for _, buf := range buffers {
+		C.buffer_ref_notify(buf.ptr, namespace, msg, sender, data)
+	}
+
+	// invalidate lifetime
+	if life != nil && name == "free" {
+		life.invalidate()
+	}
+
+	// check handler
+	if handler == nil {
+		return
+	}
+
+	// prepare data
+	var dataRef *Ref
+	if data != nil {
+		dataRef = &Ref{ptr: (*C.t_object)(data)}
+	}
+
+	// call handler
+	handler(name, &Ref{ptr: (*C.t_object)(sender), life: life}, dataRef)
+}
+
+// lifetime tracks whether an object has been freed. It is invalidated when the
+// tracking object receives the "free" notification of the object or is freed
+// itself.
+type lifetime struct {
+	alive uint32
+}
+
+func (l *lifetime) valid() bool {
+	return atomic.LoadUint32(&l.alive) == 1
+}
+
+func (l *lifetime) invalidate() {
+	atomic.StoreUint32(&l.alive, 0)
+}
+
+// bufferRef is a buffer reference that is kept by the object until it is
+// freed.
+type bufferRef struct {
+	ptr *C.t_buffer_ref
+}
+
+func (b *bufferRef) object() unsafe.Pointer {
+	// check reference
+	if b.ptr == nil {
+		return nil
+	}
+
+	return unsafe.Pointer(C.buffer_ref_getobject(b.ptr))
+}
+
+func valid(ptr unsafe.Pointer, life *lifetime, resolved bool) bool {
+	// check pointer
+	if ptr == nil {
+		return false
+	}
+
+	// check lifetime
+	if life != nil {
+		return life.valid()
+	}
+
+	// resolved pointers are always current
+	if resolved {
+		return true
+	}
+
+	// otherwise check object heuristically
+	return bool(C.maxgo_valid(ptr))
+}
+
+func (o *Object) track(ptr unsafe.Pointer) *lifetime {
+	// check existing lifetime
+	o.mutex.Lock()
+	life := o.tracked[ptr]
+	released := o.released
+	o.mutex.Unlock()
+	if life != nil {
+		return life
+	} else if released {
+		return &lifetime{}
+	}
+
+	// attach to object to receive the free notification, fall back to the
+	// heuristic check if not possible
+	if C.object_attach_byptr_register(o.ptr, ptr, gensym("nobox")) != C.MAX_ERR_NONE {
+		return nil
+	}
+
+	// store lifetime
+	life = &lifetime{alive: 1}
+	o.mutex.Lock()
+	if o.tracked == nil {
+		o.tracked = map[unsafe.Pointer]*lifetime{}
+	}
+	o.tracked[ptr] = life
+	o.mutex.Unlock()
+
+	return life
+}
+
+func (o *Object) release() {
+	// get state
+	o.mutex.Lock()
+	attached := o.attached
+	tracked := o.tracked
+	buffers := o.buffers
+	registered := o.registered
+	o.attached = nil
+	o.tracked = nil
+	o.buffers = nil
+	o.registered = false
+	o.released = true
+	o.mutex.Unlock()
+
+	// detach from all objects
+	for ptr := range attached {
+		C.object_detach_byptr(o.ptr, unsafe.Pointer(ptr))
+	}
+
+	// invalidate lifetimes and detach from tracked objects
+	for ptr, life := range tracked {
+		life.invalidate()
+		if attached[(*C.t_object)(ptr)] == nil {
+			C.object_detach_byptr(o.ptr, ptr)
+		}
+	}
+
+	// free buffer references
+	for _, buf := range buffers {
+		C.object_free(unsafe.Pointer(buf.ptr))
+		buf.ptr = nil
+	}
+
+	// unregister
+	if registered {
+		C.object_unregister(o.ptr)
+	}
+}
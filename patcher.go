@@ -0,0 +1,403 @@
+package max
+
+// #include "max.h"
+import "C"
+
+import (
+	"errors"
+	"strings"
+	"unsafe"
+)
+
+// ErrInvalidObject is returned when a patcher, box or object is not valid
+// anymore.
+var ErrInvalidObject = errors.New("invalid object")
+
+// Rect describes the position and size of a box.
+type Rect struct {
+	X, Y, Width, Height float64
+}
+
+// Patcher is a Max patcher. All methods must be called on the Max main thread,
+// e.g. from a function passed to Defer. Like with Ref, only the lifetime of
+// patchers returned by Object.Patcher is tracked.
+type Patcher struct {
+	ptr  *C.t_object
+	life *lifetime
+}
+
+// Patcher will return the patcher that contains the object. The patcher is
+// not yet available while the object is initialized, it may be accessed
+// earliest when the object has been loaded. It must be called on the Max main
+// thread.
+func (o *Object) Patcher() *Patcher {
+	// lookup patcher
+	ptr := C.maxgo_lookup(o.ptr, gensym("#P"))
+	if ptr == nil {
+		return nil
+	}
+
+	return &Patcher{ptr: ptr, life: o.track(unsafe.Pointer(ptr))}
+}
+
+// Box will return the box that contains the object. It must be called on the
+// Max main thread.
+func (o *Object) Box() *Box {
+	// lookup box
+	ptr := C.maxgo_lookup(o.ptr, gensym("#B"))
+	if ptr == nil {
+		return nil
+	}
+
+	return &Box{ptr: ptr, life: o.track(unsafe.Pointer(ptr))}
+}
+
+// Valid will return whether the patcher is still valid. See Ref for the
+// limitations of untracked patchers.
+func (p *Patcher) Valid() bool {
+	return p != nil && valid(unsafe.Pointer(p.ptr), p.life, false)
+}
+
+// Name will return the patchers name.
+func (p *Patcher) Name() string {
+	// check validity
+	if !p.Valid() {
+		return ""
+	}
+
+	return C.GoString(C.jpatcher_get_name(p.ptr).s_name)
+}
+
+// Parent will return the parent patcher or nil for top-level patchers.
+func (p *Patcher) Parent() *Patcher {
+	// check validity
+	if !p.Valid() {
+		return nil
+	}
+
+	// get parent
+	ptr := C.jpatcher_get_parentpatcher(p.ptr)
+	if ptr == nil {
+		return nil
+	}
+
+	return &Patcher{ptr: ptr}
+}
+
+// Boxes will return all boxes in the patcher.
+func (p *Patcher) Boxes() []*Box {
+	// check validity
+	if !p.Valid() {
+		return nil
+	}
+
+	// collect boxes
+	var list []*Box
+	for box := C.jpatcher_get_firstobject(p.ptr); box != nil; box = C.jbox_get_nextobject(box) {
+		list = append(list, &Box{ptr: box})
+	}
+
+	return list
+}
+
+// Box will return the box with the provided scripting name.
+func (p *Patcher) Box(name string) *Box {
+	// find box
+	for _, box := range p.Boxes() {
+		if box.Name() == name {
+			return box
+		}
+	}
+
+	return nil
+}
+
+// NewObject will create a new object box from the provided text (e.g.
+// "metro 100") at the specified position.
+func (p *Patcher) NewObject(text string, x, y float64) (*Box, error) {
+	// check validity
+	if !p.Valid() {
+		return nil, ErrInvalidObject
+	}
+
+	// escape text
+	text = strings.ReplaceAll(text, `\`, `\\`)
+	text = strings.ReplaceAll(text, `"`, `\"`)
+
+	// create object
+	ptr := C.maxgo_newobject(p.ptr, C.CString(text), C.double(x), C.double(y)) // string freed by receiver
+	if ptr == nil {
+		return nil, errors.New("failed to create object")
+	}
+
+	return &Box{ptr: ptr}, nil
+}
+
+// Connect will connect the specified outlet of the source box with the
+// specified inlet of the destination box.
+func (p *Patcher) Connect(src *Box, outlet int, dst *Box, inlet int) error {
+	return p.connect("connect", src, outlet, dst, inlet)
+}
+
+// Disconnect will remove the connection between the specified outlet of the
+// source box and the specified inlet of the destination box.
+func (p *Patcher) Disconnect(src *Box, outlet int, dst *Box, inlet int) error {
+	return p.connect("disconnect", src, outlet, dst, inlet)
+}
+
+func (p *Patcher) connect(msg string, src *Box, outlet int, dst *Box, inlet int) error {
+	// check validity
+	if !p.Valid() || !src.Valid() || !dst.Valid() {
+		return ErrInvalidObject
+	}
+
+	// send message
+	err := C.maxgo_connect(p.ptr, gensym(msg), src.ptr, C.long(outlet), dst.ptr, C.long(inlet))
+	if err != C.MAX_ERR_NONE {
+		return errors.New(msg + " failed")
+	}
+
+	return nil
+}
+
+// Connection describes a patch cord between two boxes.
+type Connection struct {
+	Src    *Box
+	Outlet int
+	Dst    *Box
+	Inlet  int
+}
+
+// Connections will return all patch cords in the patcher.
+func (p *Patcher) Connections() []Connection {
+	// check validity
+	if !p.Valid() {
+		return nil
+	}
+
+	// collect lines
+	var list []Connection
+	for line := C.jpatcher_get_firstline(p.ptr); line != nil; line = C.jpatchline_get_nextline(line) {
+		list = append(list, Connection{
+			Src:    &Box{ptr: C.jpatchline_get_box1(line)},
+			Outlet: int(C.jpatchline_get_outletnum(line)),
+			Dst:    &Box{ptr: C.jpatchline_get_box2(line)},
+			Inlet:  int(C.jpatchline_get_inletnum(line)),
+		})
+	}
+
+	return list
+}
+
+// Delete will delete the provided box from the patcher.
+func (p *Patcher) Delete(box *Box) error {
+	// check validity
+	if !p.Valid() || !box.Valid() {
+		return ErrInvalidObject
+	}
+
+	// delete box
+	C.jpatcher_deleteobj(p.ptr, (*C.t_jbox)(unsafe.Pointer(box.ptr)))
+	box.ptr = nil
+
+	return nil
+}
+
+// Box is a box in a Max patcher. All methods must be called on the Max main
+// thread, e.g. from a function passed to Defer. Like with Ref, only the
+// lifetime of boxes returned by Object.Box is tracked.
+type Box struct {
+	ptr  *C.t_object
+	life *lifetime
+}
+
+// Valid will return whether the box is still valid. See Ref for the limitations
+// of untracked boxes.
+func (b *Box) Valid() bool {
+	return b != nil && valid(unsafe.Pointer(b.ptr), b.life, false)
+}
+
+// Name will return the boxes scripting name.
+func (b *Box) Name() string {
+	// check validity
+	if !b.Valid() {
+		return ""
+	}
+
+	// get name
+	sym := C.jbox_get_varname(b.ptr)
+	if sym == nil {
+		return ""
+	}
+
+	return C.GoString(sym.s_name)
+}
+
+// SetName will set the boxes scripting name.
+func (b *Box) SetName(name string) {
+	// check validity
+	if !b.Valid() {
+		return
+	}
+
+	// set name
+	C.jbox_set_varname(b.ptr, gensym(name))
+}
+
+// Class will return the class of the boxes object.
+func (b *Box) Class() string {
+	// check validity
+	if !b.Valid() {
+		return ""
+	}
+
+	return C.GoString(C.jbox_get_maxclass(b.ptr).s_name)
+}
+
+// Rect will return the boxes patching rectangle.
+func (b *Box) Rect() Rect {
+	// check validity
+	if !b.Valid() {
+		return Rect{}
+	}
+
+	// get rect
+	var rect C.t_rect
+	C.jbox_get_patching_rect(b.ptr, &rect)
+
+	return Rect{
+		X:      float64(rect.x),
+		Y:      float64(rect.y),
+		Width:  float64(rect.width),
+		Height: float64(rect.height),
+	}
+}
+
+// SetRect will set the boxes patching rectangle.
+func (b *Box) SetRect(r Rect) {
+	// check validity
+	if !b.Valid() {
+		return
+	}
+
+	// set rect
+	rect := C.t_rect{
+		x:      C.double(r.X),
+		y:      C.double(r.Y),
+		width:  C.double(r.Width),
+		height: C.double(r.Height),
+	}
+	C.jbox_set_patching_rect(b.ptr, &rect)
+}
+
+// Move will move the box to the specified position.
+func (b *Box) Move(x, y float64) {
+	r := b.Rect()
+	r.X, r.Y = x, y
+	b.SetRect(r)
+}
+
+// Resize will resize the box to the specified size.
+func (b *Box) Resize(width, height float64) {
+	r := b.Rect()
+	r.Width, r.Height = width, height
+	b.SetRect(r)
+}
+
+// Reconfigure will replace the object with a new instance created from the
+// provided arguments, e.g. to change the number of inlets and outlets in
+// response to a message. Like retyping a box, the new box gets the same
+// position, size and scripting name and all patch cords are restored if the
+// respective inlets and outlets still exist. The replacement is deferred
+// to the Max main thread. The object is freed afterwards and must not be used
+// anymore.
+func (o *Object) Reconfigure(args ...Atom) {
+	o.Defer(func() {
+		err := o.reconfigure(args)
+		if err != nil {
+			Error("reconfigure failed: %s", err)
+		}
+	})
+}
+
+func (o *Object) reconfigure(args []Atom) error {
+	// get patcher and box
+	patcher := o.Patcher()
+	box := o.Box()
+	if !patcher.Valid() || !box.Valid() {
+		return ErrInvalidObject
+	}
+
+	// collect connections of box
+	var conns []Connection
+	for _, conn := range patcher.Connections() {
+		if conn.Src.ptr == box.ptr || conn.Dst.ptr == box.ptr {
+			conns = append(conns, conn)
+		}
+	}
+
+	// get state
+	ptr := box.ptr
+	rect := box.Rect()
+	name := box.Name()
+
+	// prepare text
+	text := className
+	if len(args) > 0 {
+		text += " " + FormatAtoms(args)
+	}
+
+	// create new box
+	newBox, err := patcher.NewObject(text, rect.X, rect.Y)
+	if err != nil {
+		return err
+	}
+
+	// restore size
+	newBox.SetRect(rect)
+
+	// get inlet and outlet counts of new object
+	var inlets, outlets int
+	if ref := newBox.Object(); ref != nil {
+		objectsMutex.Lock()
+		for _, obj := range objects {
+			if obj.ptr == unsafe.Pointer(ref.ptr) {
+				inlets, outlets = len(obj.in), len(obj.out)
+			}
+		}
+		objectsMutex.Unlock()
+	}
+	if inlets == 0 {
+		inlets = 1
+	}
+
+	// delete old box, this frees the object
+	err = patcher.Delete(box)
+	if err != nil {
+		return err
+	}
+
+	// restore name
+	if name != "" {
+		newBox.SetName(name)
+	}
+
+	// restore connections that are still possible
+	for _, conn := range conns {
+		if conn.Src.ptr == ptr {
+			if conn.Outlet >= outlets {
+				continue
+			}
+			conn.Src = newBox
+		}
+		if conn.Dst.ptr == ptr {
+			if conn.Inlet >= inlets {
+				continue
+			}
+			conn.Dst = newBox
+		}
+		_ = patcher.Connect(conn.Src, conn.Outlet, conn.Dst, conn.Inlet)
+	}
+
+	return nil
+}
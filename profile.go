@@ -0,0 +1,257 @@
+package max
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Timing describes the measured durations of a callback.
+type Timing struct {
+	Calls uint64
+	Total time.Duration
+	Max   time.Duration
+}
+
+// Average will return the average duration of a call.
+func (t Timing) Average() time.Duration {
+	if t.Calls == 0 {
+		return 0
+	}
+	return t.Total / time.Duration(t.Calls)
+}
+
+// Stats describes the measured durations of the callbacks of an object.
+type Stats struct {
+	Process  Timing
+	Handle   Timing
+	Deferred Timing
+}
+
+type timing struct {
+	calls uint64
+	total int64
+	max   int64
+}
+
+func (t *timing) record(d time.Duration) {
+	// update counters
+	atomic.AddUint64(&t.calls, 1)
+	atomic.AddInt64(&t.total, int64(d))
+
+	// update maximum
+	for {
+		old := atomic.LoadInt64(&t.max)
+		if int64(d) <= old || atomic.CompareAndSwapInt64(&t.max, old, int64(d)) {
+			return
+		}
+	}
+}
+
+func (t *timing) get() Timing {
+	return Timing{
+		Calls: atomic.LoadUint64(&t.calls),
+		Total: time.Duration(atomic.LoadInt64(&t.total)),
+		Max:   time.Duration(atomic.LoadInt64(&t.max)),
+	}
+}
+
+func (t *timing) reset() {
+	atomic.StoreUint64(&t.calls, 0)
+	atomic.StoreInt64(&t.total, 0)
+	atomic.StoreInt64(&t.max, 0)
+}
+
+type objectStats struct {
+	process  timing
+	handle   timing
+	deferred timing
+}
+
+var profiling uint32
+
+// EnableProfiling will enable or disable the measurement of the process, handle
+// and deferred callbacks of all objects. If enabled, objects also handle the
+// following messages instead of passing them to the handler:
+//
+//	stats              print the objects stats to the console
+//	stats reset        reset the objects stats
+//	pprof cpu <file> [seconds]  write a CPU profile (default 10s)
+//	pprof heap <file>  write a heap profile
+//
+// Relative profile paths are resolved against the directory of the patcher.
+func EnableProfiling(enabled bool) {
+	atomic.StoreUint32(&profiling, boolToUint32(enabled))
+}
+
+// ProfilingEnabled will return whether profiling is enabled.
+func ProfilingEnabled() bool {
+	return atomic.LoadUint32(&profiling) == 1
+}
+
+// Stats will return the measured durations of the objects callbacks.
+func (o *Object) Stats() Stats {
+	return Stats{
+		Process:  o.stats.process.get(),
+		Handle:   o.stats.handle.get(),
+		Deferred: o.stats.deferred.get(),
+	}
+}
+
+// ResetStats will reset the measured durations of the objects callbacks.
+func (o *Object) ResetStats() {
+	o.stats.process.reset()
+	o.stats.handle.reset()
+	o.stats.deferred.reset()
+}
+
+func measure(t *timing, fn func()) {
+	// run directly if disabled
+	if !ProfilingEnabled() {
+		fn()
+		return
+	}
+
+	// measure call
+	start := time.Now()
+	fn()
+	t.record(time.Since(start))
+}
+
+func (o *Object) handleProfiling(name string, atoms []Atom) bool {
+	// check profiling
+	if !ProfilingEnabled() {
+		return false
+	}
+
+	// handle message
+	switch name {
+	case "stats":
+		if len(atoms) > 0 && atoms[0] == "reset" {
+			o.ResetStats()
+			return true
+		}
+		o.printStats()
+		return true
+	case "pprof":
+		err := o.writeProfile(atoms)
+		if err != nil {
+			Error("pprof: %s", err)
+		}
+		return true
+	}
+
+	return false
+}
+
+func (o *Object) printStats() {
+	// get stats
+	stats := o.Stats()
+
+	// print stats
+	for _, item := range []struct {
+		name   string
+		timing Timing
+	}{
+		{"process", stats.Process},
+		{"handle", stats.Handle},
+		{"deferred", stats.Deferred},
+	} {
+		o.post("%s: %d calls, avg %s, max %s", item.name, item.timing.Calls, item.timing.Average(), item.timing.Max)
+	}
+}
+
+var cpuProfile sync.Mutex
+
+func (o *Object) writeProfile(atoms []Atom) error {
+	// check arguments
+	if len(atoms) < 2 {
+		return errors.New("expected kind and file")
+	}
+
+	// get kind and path
+	kind := ToString(atoms[0])
+	path := ToString(atoms[1])
+	if path == "" {
+		return errors.New("invalid file")
+	}
+
+	// resolve relative path
+	if !filepath.IsAbs(path) {
+		dir, err := DeferResult(func() (string, error) {
+			return o.Patcher().Dir(), nil
+		})
+		if err != nil {
+			return err
+		} else if dir == "" {
+			return errors.New("relative path requires a saved patcher")
+		}
+		path = filepath.Join(dir, path)
+	}
+
+	switch kind {
+	case "cpu":
+		// get duration
+		duration := 10 * time.Second
+		if len(atoms) > 2 {
+			duration = time.Duration(ToFloat(atoms[2]) * float64(time.Second))
+		}
+
+		// ensure single profile
+		if !cpuProfile.TryLock() {
+			return errors.New("cpu profile already running")
+		}
+
+		// create file
+		file, err := os.Create(path)
+		if err != nil {
+			cpuProfile.Unlock()
+			return err
+		}
+
+		// start profile
+		err = pprof.StartCPUProfile(file)
+		if err != nil {
+			_ = file.Close()
+			cpuProfile.Unlock()
+			return err
+		}
+
+		// stop profile later
+		time.AfterFunc(duration, func() {
+			pprof.StopCPUProfile()
+			_ = file.Close()
+			cpuProfile.Unlock()
+
+			// log globally as the object may have been freed in the meantime
+			Log("%s: cpu profile written to %s", className, path)
+		})
+
+		o.post("cpu profile started for %s", duration)
+
+		return nil
+	case "heap":
+		// create file
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		// write profile
+		err = pprof.WriteHeapProfile(file)
+		if err != nil {
+			return err
+		}
+
+		o.post("heap profile written to %s", path)
+
+		return nil
+	default:
+		return errors.New("unknown profile " + kind)
+	}
+}
@@ -0,0 +1,226 @@
+package max
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RealtimeConfig configures the realtime mode.
+type RealtimeConfig struct {
+	// The GC percentage as set by GOGC. A negative value disables the
+	// collector unless a memory limit is set. Zero keeps the current value.
+	GCPercent int
+
+	// The soft memory limit in bytes. It requires Go 1.19 or later. Zero
+	// keeps the current limit.
+	MemoryLimit int64
+
+	// The minimum interval between collections run on the Max main thread
+	// using Defer. A collection is only run if no messages have been handled
+	// and no deferred calls have been run since the last check, which happens
+	// every 100ms. Running collections when the main thread is idle reduces
+	// the likelihood of collections triggered from the audio thread. Zero
+	// disables idle collections.
+	IdleGC time.Duration
+
+	// Whether to lock the goroutine to the audio thread while processing using
+	// runtime.LockOSThread.
+	LockThread bool
+
+	// Whether to report GC pauses that overlapped with audio processing to
+	// the Max console.
+	ReportPauses bool
+}
+
+// RealtimeStats describes the GC activity observed in realtime mode.
+type RealtimeStats struct {
+	// The number of collections.
+	Collections int64
+
+	// The number of GC pauses that overlapped with audio processing.
+	Overlaps int64
+
+	// The longest GC pause that overlapped with audio processing.
+	LongestOverlap time.Duration
+}
+
+// Realtime will configure the Go runtime to reduce GC induced audio glitches.
+// It should be called from the main packages main() function, which is run
+// when Max loads the external. Calling it again will replace the
+// configuration.
+//
+// Note that cgo callbacks such as the process callback already run on the
+// calling audio thread. LockThread additionally locks the goroutine explicitly
+// for the duration of the callback. GC pauses stop all goroutines, processing
+// code should still avoid allocations to reduce the amount of work for the
+// collector.
+func Realtime(cfg RealtimeConfig) {
+	// acquire mutex
+	realtimeMutex.Lock()
+	defer realtimeMutex.Unlock()
+
+	// set GC percent
+	if cfg.GCPercent != 0 {
+		debug.SetGCPercent(cfg.GCPercent)
+	}
+
+	// set memory limit
+	if cfg.MemoryLimit != 0 && !setMemoryLimit(cfg.MemoryLimit) {
+		Error("memory limit requires Go 1.19 or later")
+	}
+
+	// stop previous monitor
+	if realtimeStop != nil {
+		close(realtimeStop)
+		realtimeStop = nil
+	}
+
+	// set process tracking and thread locking
+	atomic.StoreUint32(&trackProcess, boolToUint32(cfg.ReportPauses))
+	atomic.StoreUint32(&lockProcess, boolToUint32(cfg.LockThread))
+
+	// set activity tracking
+	atomic.StoreUint32(&trackActivity, boolToUint32(cfg.IdleGC > 0))
+
+	// check monitor
+	if cfg.IdleGC <= 0 && !cfg.ReportPauses {
+		return
+	}
+
+	// start monitor
+	realtimeStop = make(chan struct{})
+	go monitorGC(cfg.IdleGC, cfg.ReportPauses, realtimeStop)
+}
+
+// GetRealtimeStats will return the GC activity observed in realtime mode.
+func GetRealtimeStats() RealtimeStats {
+	// acquire mutex
+	realtimeMutex.Lock()
+	defer realtimeMutex.Unlock()
+
+	return realtimeStats
+}
+
+var realtimeMutex sync.Mutex
+var realtimeStop chan struct{}
+var realtimeStats RealtimeStats
+
+// processSpans is a ring of recent processing intervals as unix nanoseconds.
+// It is large enough to cover the check interval for many objects.
+var processSpans [16384]struct{ start, end int64 }
+var processIndex uint64
+var trackProcess uint32
+var lockProcess uint32
+
+// lastActivity is the time of the last handled message or deferred call as
+// unix nanoseconds.
+var lastActivity int64
+var trackActivity uint32
+
+func trackingProcess() bool {
+	return atomic.LoadUint32(&trackProcess) == 1
+}
+
+func lockingProcess() bool {
+	return atomic.LoadUint32(&lockProcess) == 1
+}
+
+func recordActivity() {
+	// store time if tracked
+	if atomic.LoadUint32(&trackActivity) == 1 {
+		atomic.StoreInt64(&lastActivity, time.Now().UnixNano())
+	}
+}
+
+func recordProcess(start, end time.Time) {
+	// claim slot
+	i := (atomic.AddUint64(&processIndex, 1) - 1) % uint64(len(processSpans))
+
+	// store interval
+	atomic.StoreInt64(&processSpans[i].start, start.UnixNano())
+	atomic.StoreInt64(&processSpans[i].end, end.UnixNano())
+}
+
+func overlapsProcess(start, end int64) bool {
+	// check intervals
+	for i := range processSpans {
+		s := atomic.LoadInt64(&processSpans[i].start)
+		e := atomic.LoadInt64(&processSpans[i].end)
+		if s < end && start < e {
+			return true
+		}
+	}
+
+	return false
+}
+
+func monitorGC(idle time.Duration, report bool, stop chan struct{}) {
+	// get current stats
+	var stats debug.GCStats
+	debug.ReadGCStats(&stats)
+	last := stats.NumGC
+
+	// create ticker, pauses are checked frequently as the process intervals
+	// only cover a short period
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	// run loop
+	lastIdle := time.Now()
+	lastTick := lastIdle
+	for {
+		// await tick
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+
+		// run idle collection if due and there was no activity since the
+		// last tick
+		active := atomic.LoadInt64(&lastActivity) >= lastTick.UnixNano()
+		if idle > 0 && !active && time.Since(lastIdle) >= idle {
+			_ = DeferSync(func() error {
+				runtime.GC()
+				return nil
+			})
+			lastIdle = time.Now()
+		}
+		lastTick = time.Now()
+
+		// read stats
+		debug.ReadGCStats(&stats)
+
+		// check new pauses, the most recent pause is first
+		var overlaps int64
+		var longest time.Duration
+		for i := 0; i < int(stats.NumGC-last) && i < len(stats.Pause) && i < len(stats.PauseEnd); i++ {
+			end := stats.PauseEnd[i].UnixNano()
+			start := end - int64(stats.Pause[i])
+			if overlapsProcess(start, end) {
+				overlaps++
+				if stats.Pause[i] > longest {
+					longest = stats.Pause[i]
+				}
+			}
+		}
+
+		// update stats
+		realtimeMutex.Lock()
+		realtimeStats.Collections += stats.NumGC - last
+		realtimeStats.Overlaps += overlaps
+		if longest > realtimeStats.LongestOverlap {
+			realtimeStats.LongestOverlap = longest
+		}
+		realtimeMutex.Unlock()
+		last = stats.NumGC
+
+		// report overlaps
+		if report && overlaps > 0 {
+			Error("%d GC pauses overlapped with audio processing (longest %s)", overlaps, longest)
+		}
+	}
+}
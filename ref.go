@@ -0,0 +1,181 @@
+package max
+
+// #include "max.h"
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// Ref is a reference to an arbitrary Max object. References are obtained from
+// boxes, registered objects or object atoms. Max may free the referenced object
+// at any time, all methods therefore check the validity of the object before
+// accessing it. All methods must be called on the Max main thread, e.g. from a
+// function passed to Defer.
+//
+// The lifetime of references returned by Object.Attach and Object.Buffer is
+// tracked by the object using notifications. The validity of other references
+// can only be checked heuristically, which may access freed memory if the
+// object has been freed in the meantime.
+type Ref struct {
+	ptr  *C.t_object
+	life *lifetime
+	buf  *bufferRef
+}
+
+// Lookup will return a reference to the object registered with the provided
+// name in the specified namespace (e.g. "box" or "nobox").
+func Lookup(namespace, name string) *Ref {
+	// find object
+	ptr := C.object_findregistered(gensym(namespace), gensym(name))
+	if ptr == nil {
+		return nil
+	}
+
+	return &Ref{ptr: (*C.t_object)(ptr)}
+}
+
+// Object will return a reference to the object contained in the box.
+func (b *Box) Object() *Ref {
+	// check validity
+	if !b.Valid() {
+		return nil
+	}
+
+	// get object
+	ptr := C.jbox_get_object(b.ptr)
+	if ptr == nil {
+		return nil
+	}
+
+	return &Ref{ptr: ptr}
+}
+
+// Valid will return whether the referenced object is still valid. See Ref for
+// the limitations of untracked references.
+func (r *Ref) Valid() bool {
+	if r == nil {
+		return false
+	}
+
+	return valid(r.object(), r.life, r.buf != nil)
+}
+
+func (r *Ref) object() unsafe.Pointer {
+	// resolve buffer
+	if r.buf != nil {
+		return r.buf.object()
+	}
+
+	return unsafe.Pointer(r.ptr)
+}
+
+// Class will return the class name of the referenced object.
+func (r *Ref) Class() string {
+	// check validity
+	if !r.Valid() {
+		return ""
+	}
+
+	return C.GoString(C.object_classname(r.object()).s_name)
+}
+
+// Call will call the specified method with the provided atoms and return the
+// result if any.
+func (r *Ref) Call(method string, atoms ...Atom) (Atom, error) {
+	// check validity
+	if !r.Valid() {
+		return nil, ErrInvalidObject
+	}
+
+	// encode atoms
+	argc, argv := encodeAtoms(atoms)
+	if argv != nil {
+		defer C.freebytes(unsafe.Pointer(argv), C.t_getbytes_size(argc*C.sizeof_t_atom))
+	}
+
+	// call method
+	var ret C.t_atom
+	err := C.object_method_typed(r.object(), gensym(method), C.long(argc), argv, &ret)
+	if err != C.MAX_ERR_NONE {
+		return nil, fmt.Errorf("calling %s failed (%d)", method, int(err))
+	}
+
+	// decode result
+	result := decodeAtoms(1, &ret)
+
+	return result[0], nil
+}
+
+// GetAttr will return the value of the specified attribute.
+func (r *Ref) GetAttr(name string) ([]Atom, error) {
+	// check validity
+	if !r.Valid() {
+		return nil, ErrInvalidObject
+	}
+
+	// get value
+	var argc C.long
+	var argv *C.t_atom
+	err := C.object_attr_getvalueof(r.object(), gensym(name), &argc, &argv)
+	if err != C.MAX_ERR_NONE {
+		return nil, fmt.Errorf("getting %s failed (%d)", name, int(err))
+	}
+
+	// free value
+	if argv != nil {
+		defer C.sysmem_freeptr(unsafe.Pointer(argv))
+	}
+
+	return decodeAtoms(int64(argc), argv), nil
+}
+
+// SetAttr will set the value of the specified attribute.
+func (r *Ref) SetAttr(name string, atoms ...Atom) error {
+	// check validity
+	if !r.Valid() {
+		return ErrInvalidObject
+	}
+
+	// encode atoms
+	argc, argv := encodeAtoms(atoms)
+	if argv != nil {
+		defer C.freebytes(unsafe.Pointer(argv), C.t_getbytes_size(argc*C.sizeof_t_atom))
+	}
+
+	// set value
+	err := C.object_attr_setvalueof(r.object(), gensym(name), C.long(argc), argv)
+	if err != C.MAX_ERR_NONE {
+		return fmt.Errorf("setting %s failed (%d)", name, int(err))
+	}
+
+	return nil
+}
+
+// Attrs will return the names of all attributes of the referenced object.
+func (r *Ref) Attrs() []string {
+	// check validity
+	if !r.Valid() {
+		return nil
+	}
+
+	// get names
+	var argc C.long
+	var argv **C.t_symbol
+	err := C.object_attr_getnames(r.object(), &argc, &argv)
+	if err != C.MAX_ERR_NONE || argv == nil {
+		return nil
+	}
+
+	// free names
+	defer C.sysmem_freeptr(unsafe.Pointer(argv))
+
+	// convert names
+	names := make([]string, 0, int(argc))
+	for _, sym := range unsafe.Slice(argv, int(argc)) {
+		names = append(names, C.GoString(sym.s_name))
+	}
+
+	return names
+}
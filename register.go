@@ -3,6 +3,8 @@ package max
 import (
 	"reflect"
 	"sync"
+
+	"github.com/256dpi/max-go/internal/block"
 )
 
 // Instance is a generic object instance.
@@ -23,6 +25,13 @@ type ProcessingInstance interface {
 	Process(input, output [][]float64)
 }
 
+// EventProcessingInstance is an object that processes audio together with the
+// control events posted using Object.Post. It is used instead of
+// ProcessingInstance if implemented.
+type EventProcessingInstance interface {
+	ProcessEvents(input, output [][]float64, events []ProcessEvent)
+}
+
 // Register will initialize the Max class using the provided instance. This
 // function must be called from the main packages main() function. The instance
 // methods are usually called on the Max main thread. However, the handler may
@@ -31,8 +40,9 @@ func Register(name string, prototype Instance) {
 	// create mutex
 	var mutex sync.Mutex
 
-	// create instance map
+	// create instance and buffer maps
 	instances := map[*Object]Instance{}
+	buffers := map[*Object]*processBuffers{}
 
 	// get type
 	typ := reflect.TypeOf(prototype).Elem()
@@ -48,9 +58,25 @@ func Register(name string, prototype Instance) {
 			return false
 		}
 
+		// prepare buffers
+		bufs := newProcessBuffers(instance, obj.signalInlets(), obj.signalOutlets(), BlockSize())
+
+		// prepare block buffer and report latency
+		if pro, ok := instance.(BlockProcessingInstance); ok {
+			size, hop := pro.BlockSize()
+			if size <= 0 || hop <= 0 || hop > size {
+				Error("%s: invalid block size %d or hop %d", name, size, hop)
+				instance.Free()
+				return false
+			}
+			bufs.block = block.New(size, hop, obj.signalInlets(), obj.signalOutlets())
+			obj.SetLatency(size)
+		}
+
 		// store instance
 		mutex.Lock()
 		instances[obj] = instance
+		buffers[obj] = bufs
 		mutex.Unlock()
 
 		return true
@@ -81,9 +107,10 @@ func Register(name string, prototype Instance) {
 		// handle message
 		instance.Handle(inlet, msg, atoms)
 	}, func(obj *Object, input, output [][]float64) {
-		// get instance
+		// get instance and buffers
 		mutex.Lock()
 		instance := instances[obj]
+		bufs := buffers[obj]
 		mutex.Unlock()
 
 		// return if nil
@@ -92,7 +119,15 @@ func Register(name string, prototype Instance) {
 		}
 
 		// process audio
-		if pro, ok := instance.(ProcessingInstance); ok {
+		if pro, ok := instance.(EventProcessingInstance); ok {
+			pro.ProcessEvents(input, output, obj.Events())
+		} else if pro, ok := instance.(BlockProcessingInstance); ok {
+			bufs.block.Process(pro, input, output)
+		} else if pro, ok := instance.(Float32ProcessingInstance); ok {
+			bufs.processFloat32(pro, input, output)
+		} else if pro, ok := instance.(InterleavedProcessingInstance); ok {
+			bufs.processInterleaved(pro, input, output)
+		} else if pro, ok := instance.(ProcessingInstance); ok {
 			pro.Process(input, output)
 		}
 	}, func(obj *Object) {
@@ -100,6 +135,7 @@ func Register(name string, prototype Instance) {
 		mutex.Lock()
 		instance := instances[obj]
 		delete(instances, obj)
+		delete(buffers, obj)
 		mutex.Unlock()
 
 		// return if nil
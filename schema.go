@@ -0,0 +1,301 @@
+package max
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Symbol is the parameter type of symbols. It may only be used in message
+// and argument schemas.
+const Symbol Type = "symbol"
+
+// Message is the schema of a message accepted by an object.
+type Message struct {
+	name     string
+	types    []Type
+	labels   []string
+	optional int
+}
+
+// Message will declare a message with the provided parameter types (Int,
+// Float, Symbol or Any). Received messages with the same name are validated
+// against the schema before they are handled. Ints are converted to floats and
+// floats truncated to ints if necessary. Invalid messages are reported to the
+// console and dropped. Undeclared messages are not validated.
+//
+// Messages are matched on all inlets, the messages "int", "float" and "list"
+// may be declared to validate the input of the respective inlets.
+func (o *Object) Message(name string, types ...Type) *Message {
+	// check types
+	for _, typ := range types {
+		checkParam(typ)
+	}
+
+	// create message
+	msg := &Message{name: name, types: types}
+
+	// store message
+	o.messages = append(o.messages, msg)
+
+	return msg
+}
+
+// Label will set the labels of the parameters which are used for errors and
+// assist text.
+func (m *Message) Label(labels ...string) *Message {
+	m.labels = labels
+	return m
+}
+
+// Optional will mark the specified number of trailing parameters as optional.
+func (m *Message) Optional(n int) *Message {
+	if n < 0 || n > len(m.types) {
+		panic("invalid number of optional parameters")
+	}
+	m.optional = n
+	return m
+}
+
+// Name will return the messages name.
+func (m *Message) Name() string {
+	return m.name
+}
+
+// Validate will validate and convert the provided atoms.
+func (m *Message) Validate(atoms []Atom) ([]Atom, error) {
+	return validateParams(m.types, m.labels, m.optional, atoms)
+}
+
+// String will return the signature of the message, e.g. "setrange min:float
+// [max:float]".
+func (m *Message) String() string {
+	// prepare parts
+	parts := []string{m.name}
+
+	// add params
+	for i, typ := range m.types {
+		param := paramName(typ, m.labels, i)
+		if i >= len(m.types)-m.optional {
+			param = "[" + param + "]"
+		}
+		parts = append(parts, param)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func (o *Object) message(name string) *Message {
+	// find message
+	for _, msg := range o.messages {
+		if msg.name == name {
+			return msg
+		}
+	}
+
+	return nil
+}
+
+func (o *Object) signatures(typ Type) []string {
+	// collect signatures
+	var list []string
+	for _, msg := range o.messages {
+		if typ == Any || Type(msg.name) == typ {
+			list = append(list, msg.String())
+		}
+	}
+
+	return list
+}
+
+// Arguments will validate the provided creation arguments and store them in
+// the fields of the provided struct pointer. Fields are filled in declaration
+// order and may be of an int, float, string or Atom type. Optional fields and
+// labels are configured using a `max:"label,optional"` tag, fields tagged with
+// `max:"-"` and unexported fields are skipped. Fields of omitted optional
+// arguments keep their value. Invalid arguments are reported to the console and
+// false is returned.
+func (o *Object) Arguments(args []Atom, spec interface{}) bool {
+	// parse arguments
+	err := ParseArguments(args, spec)
+	if err != nil {
+		Error("invalid arguments: %s", err)
+		return false
+	}
+
+	return true
+}
+
+// ParseArguments will validate the provided atoms and store them in the fields
+// of the provided struct pointer. See Object.Arguments for details.
+func ParseArguments(atoms []Atom, spec interface{}) error {
+	// get struct
+	value := reflect.ValueOf(spec)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		panic("expected struct pointer")
+	}
+	value = value.Elem()
+
+	// collect fields
+	var fields []reflect.Value
+	var types []Type
+	var labels []string
+	var optional int
+	for i := 0; i < value.NumField(); i++ {
+		// get field
+		field := value.Type().Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		// parse tag
+		tag := strings.Split(field.Tag.Get("max"), ",")
+		if tag[0] == "-" {
+			continue
+		}
+		label := strings.ToLower(field.Name)
+		if tag[0] != "" {
+			label = tag[0]
+		}
+
+		// check optional
+		if len(tag) > 1 && tag[1] == "optional" {
+			optional++
+		} else if optional > 0 {
+			panic("required field " + field.Name + " after optional field")
+		}
+
+		// get type
+		var typ Type
+		switch field.Type.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			typ = Int
+		case reflect.Float32, reflect.Float64:
+			typ = Float
+		case reflect.String:
+			typ = Symbol
+		case reflect.Interface:
+			if field.Type.NumMethod() > 0 {
+				panic("unsupported type of field " + field.Name)
+			}
+			typ = Any
+		default:
+			panic("unsupported type of field " + field.Name)
+		}
+
+		// add field
+		fields = append(fields, value.Field(i))
+		types = append(types, typ)
+		labels = append(labels, label)
+	}
+
+	// validate atoms
+	atoms, err := validateParams(types, labels, optional, atoms)
+	if err != nil {
+		return err
+	}
+
+	// set fields
+	for i, atom := range atoms {
+		switch types[i] {
+		case Int:
+			fields[i].SetInt(atom.(int64))
+		case Float:
+			fields[i].SetFloat(atom.(float64))
+		case Symbol:
+			fields[i].SetString(atom.(string))
+		default:
+			fields[i].Set(reflect.ValueOf(&atom).Elem())
+		}
+	}
+
+	return nil
+}
+
+func checkParam(typ Type) {
+	// check type
+	switch typ {
+	case Int, Float, Symbol, Any:
+	default:
+		panic("invalid parameter type")
+	}
+}
+
+func validateParams(types []Type, labels []string, optional int, atoms []Atom) ([]Atom, error) {
+	// check count
+	if len(atoms) < len(types)-optional {
+		return nil, fmt.Errorf("expected at least %d arguments, got %d", len(types)-optional, len(atoms))
+	} else if len(atoms) > len(types) {
+		return nil, fmt.Errorf("expected at most %d arguments, got %d", len(types), len(atoms))
+	}
+
+	// validate atoms
+	list := make([]Atom, len(atoms))
+	for i, atom := range atoms {
+		// convert atom
+		switch types[i] {
+		case Int:
+			switch v := atom.(type) {
+			case int64:
+				list[i] = v
+			case float64:
+				list[i] = int64(v)
+			}
+		case Float:
+			switch v := atom.(type) {
+			case int64:
+				list[i] = float64(v)
+			case float64:
+				list[i] = v
+			}
+		case Symbol:
+			if v, ok := atom.(string); ok {
+				list[i] = v
+			}
+		case Any:
+			if _, ok := atom.(Separator); !ok {
+				list[i] = atom
+			}
+		}
+
+		// check result
+		if list[i] == nil {
+			name := fmt.Sprintf("argument %d", i+1)
+			if i < len(labels) && labels[i] != "" {
+				name += " (" + labels[i] + ")"
+			}
+			return nil, fmt.Errorf("expected %s for %s, got %s", types[i], name, atomType(atom))
+		}
+	}
+
+	return list, nil
+}
+
+func paramName(typ Type, labels []string, i int) string {
+	// check label
+	if i < len(labels) && labels[i] != "" {
+		return labels[i] + ":" + string(typ)
+	}
+
+	return string(typ)
+}
+
+func atomType(atom Atom) string {
+	// get type
+	switch atom.(type) {
+	case int64:
+		return "int"
+	case float64:
+		return "float"
+	case string:
+		return "symbol"
+	case *Ref:
+		return "object"
+	case Separator:
+		return "separator"
+	case Dollar, DollarSymbol:
+		return "dollar"
+	default:
+		return "nothing"
+	}
+}
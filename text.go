@@ -0,0 +1,69 @@
+package max
+
+import "github.com/256dpi/max-go/internal/text"
+
+// ParseAtoms will parse the provided text into atoms following the rules of
+// Max message boxes and text files. Integers and floats are parsed as numbers,
+// double-quoted text and unquoted words as symbols, commas and semicolons as
+// separators and $1-style arguments as dollar atoms. Backslashes escape the
+// following character.
+func ParseAtoms(str string) ([]Atom, error) {
+	// parse text
+	tokens, err := text.Parse(str)
+	if err != nil {
+		return nil, err
+	}
+
+	// convert tokens
+	var atoms []Atom
+	for _, token := range tokens {
+		switch token.Kind {
+		case text.Int:
+			atoms = append(atoms, token.Int)
+		case text.Float:
+			atoms = append(atoms, token.Float)
+		case text.Symbol:
+			atoms = append(atoms, token.Symbol)
+		case text.Separator:
+			atoms = append(atoms, Separator(token.Symbol[0]))
+		case text.Dollar:
+			atoms = append(atoms, Dollar(token.Int))
+		case text.DollarSymbol:
+			atoms = append(atoms, DollarSymbol(token.Symbol))
+		}
+	}
+
+	return atoms, nil
+}
+
+// FormatAtoms will format the provided atoms as text that can be parsed by
+// ParseAtoms and Max. Symbols are quoted or escaped if necessary and floats
+// always contain a decimal point. Infinite and NaN floats have no textual
+// representation and are formatted as "inf", "-inf" and "nan" like Max does,
+// which are parsed back as symbols.
+func FormatAtoms(atoms []Atom) string {
+	// convert atoms
+	tokens := make([]text.Token, 0, len(atoms))
+	for _, atom := range atoms {
+		switch atom := atom.(type) {
+		case int64:
+			tokens = append(tokens, text.Token{Kind: text.Int, Int: atom})
+		case float64:
+			tokens = append(tokens, text.Token{Kind: text.Float, Float: atom})
+		case string:
+			tokens = append(tokens, text.Token{Kind: text.Symbol, Symbol: atom})
+		case Separator:
+			tokens = append(tokens, text.Token{Kind: text.Separator, Symbol: string(rune(atom))})
+		case Dollar:
+			tokens = append(tokens, text.Token{Kind: text.Dollar, Int: int64(atom)})
+		case DollarSymbol:
+			tokens = append(tokens, text.Token{Kind: text.DollarSymbol, Symbol: string(atom)})
+		case *Ref:
+			tokens = append(tokens, text.Token{Kind: text.Object})
+		default:
+			tokens = append(tokens, text.Token{Kind: text.Unknown})
+		}
+	}
+
+	return text.Format(tokens)
+}
@@ -41,6 +41,12 @@ func ToString(atom Atom) string {
 		return strconv.FormatFloat(v, 'f', -1, 64)
 	case string:
 		return v
+	case Separator:
+		return string(rune(v))
+	case Dollar:
+		return "$" + strconv.FormatInt(int64(v), 10)
+	case DollarSymbol:
+		return string(v)
 	default:
 		return ""
 	}
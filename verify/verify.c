@@ -5,36 +5,92 @@
 
 void atom_getfloat(void) { printf("%s\n", __func__); }
 void atom_getlong(void) { printf("%s\n", __func__); }
+void atom_getobj(void) { printf("%s\n", __func__); }
 void atom_getsym(void) { printf("%s\n", __func__); }
 void atom_setfloat(void) { printf("%s\n", __func__); }
 void atom_setlong(void) { printf("%s\n", __func__); }
+void atom_setobj(void) { printf("%s\n", __func__); }
 void atom_setsym(void) { printf("%s\n", __func__); }
+void attr_offset_new(void) { printf("%s\n", __func__); }
 void bangout(void) { printf("%s\n", __func__); }
+void buffer_ref_getobject(void) { printf("%s\n", __func__); }
+void buffer_ref_new(void) { printf("%s\n", __func__); }
+void buffer_ref_notify(void) { printf("%s\n", __func__); }
+void class_addattr(void) { printf("%s\n", __func__); }
 void class_addmethod(void) { printf("%s\n", __func__); }
 void class_dspinit(void) { printf("%s\n", __func__); }
 void class_new(void) { printf("%s\n", __func__); }
+void class_obexoffset_set(void) { printf("%s\n", __func__); }
 void class_register(void) { printf("%s\n", __func__); }
 void clock_delay(void) { printf("%s\n", __func__); }
+void clock_getftime(void) { printf("%s\n", __func__); }
 void clock_new(void) { printf("%s\n", __func__); }
+void defer_front(void) { printf("%s\n", __func__); }
 void defer_low(void) { printf("%s\n", __func__); }
 void clock_unset(void) { printf("%s\n", __func__); }
 void floatout(void) { printf("%s\n", __func__); }
 void freeobject(void) { printf("%s\n", __func__); }
 void gensym(void) { printf("%s\n", __func__); }
 void intout(void) { printf("%s\n", __func__); }
+void jbox_get_maxclass(void) { printf("%s\n", __func__); }
+void jbox_get_nextobject(void) { printf("%s\n", __func__); }
+void jbox_get_object(void) { printf("%s\n", __func__); }
+void jbox_get_patching_rect(void) { printf("%s\n", __func__); }
+void jbox_get_varname(void) { printf("%s\n", __func__); }
+void jbox_set_patching_rect(void) { printf("%s\n", __func__); }
+void jbox_set_varname(void) { printf("%s\n", __func__); }
+void jpatcher_deleteobj(void) { printf("%s\n", __func__); }
+void jpatcher_get_filepath(void) { printf("%s\n", __func__); }
+void jpatcher_get_firstline(void) { printf("%s\n", __func__); }
+void jpatcher_get_firstobject(void) { printf("%s\n", __func__); }
+void jpatcher_get_name(void) { printf("%s\n", __func__); }
+void jpatcher_get_parentpatcher(void) { printf("%s\n", __func__); }
+void jpatchline_get_box1(void) { printf("%s\n", __func__); }
+void jpatchline_get_box2(void) { printf("%s\n", __func__); }
+void jpatchline_get_inletnum(void) { printf("%s\n", __func__); }
+void jpatchline_get_nextline(void) { printf("%s\n", __func__); }
+void jpatchline_get_outletnum(void) { printf("%s\n", __func__); }
 void listout(void) { printf("%s\n", __func__); }
+void locatefile_extended(void) { printf("%s\n", __func__); }
+void newobject_sprintf(void) { printf("%s\n", __func__); }
 void object_alloc(void) { printf("%s\n", __func__); }
+void object_attach(void) { printf("%s\n", __func__); }
+void object_attach_byptr_register(void) { printf("%s\n", __func__); }
+void object_attr_getnames(void) { printf("%s\n", __func__); }
+void object_attr_getvalueof(void) { printf("%s\n", __func__); }
+void object_attr_setvalueof(void) { printf("%s\n", __func__); }
+void object_attr_touch(void) { printf("%s\n", __func__); }
+void object_classname(void) { printf("%s\n", __func__); }
+void object_detach(void) { printf("%s\n", __func__); }
+void object_detach_byptr(void) { printf("%s\n", __func__); }
+void object_findregistered(void) { printf("%s\n", __func__); }
 void object_free(void) { printf("%s\n", __func__); }
 void object_method_imp(void) { printf("%s\n", __func__); }
+void object_method_typed(void) { printf("%s\n", __func__); }
+void object_notify(void) { printf("%s\n", __func__); }
+void object_obex_lookup(void) { printf("%s\n", __func__); }
+void object_post(void) { printf("%s\n", __func__); }
+void object_register(void) { printf("%s\n", __func__); }
+void object_unregister(void) { printf("%s\n", __func__); }
+void open_dialog(void) { printf("%s\n", __func__); }
+void open_promptset(void) { printf("%s\n", __func__); }
 void outlet_anything(void) { printf("%s\n", __func__); }
 void outlet_bang(void) { printf("%s\n", __func__); }
 void outlet_float(void) { printf("%s\n", __func__); }
 void outlet_int(void) { printf("%s\n", __func__); }
 void outlet_list(void) { printf("%s\n", __func__); }
 void outlet_new(void) { printf("%s\n", __func__); }
+void path_nameconform(void) { printf("%s\n", __func__); }
+void path_toabsolutesystempath(void) { printf("%s\n", __func__); }
 void proxy_getinlet(void) { printf("%s\n", __func__); }
 void proxy_new(void) { printf("%s\n", __func__); }
+void saveas_promptset(void) { printf("%s\n", __func__); }
+void saveasdialog_extended(void) { printf("%s\n", __func__); }
+void schedule_fdelay(void) { printf("%s\n", __func__); }
+void sndfile_info(void) { printf("%s\n", __func__); }
 void strncpy_zero(void) { printf("%s\n", __func__); }
+void sys_getblksize(void) { printf("%s\n", __func__); }
+void sys_getsr(void) { printf("%s\n", __func__); }
 void sysmem_freeptr(void) { printf("%s\n", __func__); }
 void sysmem_newptr(void) { printf("%s\n", __func__); }
 void systhread_ismainthread(void) { printf("%s\n", __func__); }